@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/okieoth/ic0bra"
@@ -13,12 +14,9 @@ var rootCmd = &cobra.Command{
 	Short: "Example for ic0bra integration with flag history",
 	Long:  `Example for ic0bra integration with flag history to provide an advanced interactive option for command line tools`,
 	Run: func(cmd *cobra.Command, args []string) {
-		// the history will be stored in this case in ~/.config/ic0bra/history/*
-		if cmdToCall, err := ic0bra.RunInteractiveWithHistory(cmd, "ic0bra"); err == nil {
-			if cmdToCall != nil {
-				cmdToCall.Run(cmdToCall, args)
-			}
-		} else {
+		// the history will be stored in this case in ~/.config/ic0bra/history/*,
+		// the wizard doesn't offer to run the root itself, so it isn't started again
+		if err := ic0bra.ExecuteInteractiveWithHistory(cmd, "ic0bra"); err != nil && !errors.Is(err, ic0bra.ErrCancelled) {
 			fmt.Println("error while running in interactive mode:", err)
 		}
 	},
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/okieoth/ic0bra_examples/helper"
+	"github.com/spf13/cobra"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Example for the installation of the interactive mode",
+	Long:  `Example that adds the --interactive flag and the interactive sub command to a command line tool`,
+}
+
+func init() {
+	helper.AddSubCommands(rootCmd)
+	// `install -i`, `install cmd1 -i` and `install interactive` start the wizard
+	if err := ic0bra.Install(rootCmd); err != nil {
+		panic(err)
+	}
+}
+
+func main() {
+	fmt.Println("I am an example for the installation of the interactive mode.")
+	// returns the error of the command selected in the wizard, too
+	if err := ic0bra.Execute(rootCmd); err != nil {
+		os.Exit(1)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/okieoth/ic0bra"
@@ -13,11 +14,8 @@ var rootCmd = &cobra.Command{
 	Short: "Simple example for ic0bra integration",
 	Long:  `Simple example for ic0bra integration for providing an interactive option for command line tools`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if cmdToCall, err := ic0bra.RunInteractive(cmd); err == nil {
-			if cmdToCall != nil {
-				cmdToCall.Run(cmdToCall, args)
-			}
-		} else {
+		// the wizard doesn't offer to run the root itself, so it isn't started again
+		if err := ic0bra.ExecuteInteractive(cmd); err != nil && !errors.Is(err, ic0bra.ErrCancelled) {
 			fmt.Println("error while running in interactive mode:", err)
 		}
 	},
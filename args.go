@@ -0,0 +1,237 @@
+package ic0bra
+
+import (
+	"bufio"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// max number of additional positional args used to find out if the args
+// validator of a command can still be satisfied
+const maxArgProbes = 10
+
+// value used to probe args validators, if the command provides no valid args
+const argProbe = "arg"
+
+// prefix cobra uses to mark active help entries in completion results
+const activeHelpPrefix = "_activeHelp_ "
+
+// a value proposed for input, e.g. a valid arg or a flag completion
+type candidate struct {
+	value       string
+	description string
+}
+
+// returns true if the command expects positional arguments that should be
+// asked for
+func hasPositionalArgs(cmd *cobra.Command) bool {
+	if positionalArgs(cmd) != nil || len(cmd.ValidArgs) > 0 || cmd.ValidArgsFunction != nil {
+		return true
+	}
+	// e.g. `Use: "get [name]"`
+	return len(strings.Fields(cmd.Use)) > 1
+}
+
+// returns true if the args validator of the command accepts the given args
+func argsValid(cmd *cobra.Command, args []string) bool {
+	return validateArgs(cmd, args) == nil
+}
+
+// validates the args with the args validator of the command, commands
+// without validator accept all args
+func validateArgs(cmd *cobra.Command, args []string) error {
+	validator := positionalArgs(cmd)
+	if validator == nil {
+		return nil
+	}
+	return validator(cmd, args)
+}
+
+// returns true if the given args, possibly extended by more args, can
+// satisfy the args validator of the command
+func argsReachable(cmd *cobra.Command, args []string) bool {
+	return probeArgs(cmd, args, 0)
+}
+
+// returns true if more args can follow the given ones
+func moreArgsPossible(cmd *cobra.Command, args []string) bool {
+	return probeArgs(cmd, args, 1)
+}
+
+// returns true if the args validator accepts the given args extended by at
+// least minArgs probe values
+func probeArgs(cmd *cobra.Command, args []string, minArgs int) bool {
+	for _, probe := range argProbeValues(cmd, args) {
+		probed := slices.Clone(args)
+		for n := range maxArgProbes + 1 {
+			if n >= minArgs && argsValid(cmd, probed) {
+				return true
+			}
+			probed = append(probed, probe)
+		}
+	}
+	return false
+}
+
+// returns the values used to probe the args validator. Validators can check
+// the content of the args, so the last given arg is used as well.
+func argProbeValues(cmd *cobra.Command, args []string) []string {
+	ret := []string{argProbe}
+	if len(cmd.ValidArgs) > 0 {
+		ret[0] = parseCandidate(cmd.ValidArgs[0]).value
+	}
+	if len(args) > 0 {
+		ret = append(ret, args[len(args)-1])
+	}
+	return ret
+}
+
+// returns a displayable name for the positional arg with the given index,
+// based on the placeholders in the Use string of the command
+func argName(cmd *cobra.Command, idx int) string {
+	// the first field is the name of the command, Use can be empty
+	placeholders := strings.Fields(cmd.Use)
+	if len(placeholders) < 2 {
+		return "arg"
+	}
+	placeholders = placeholders[1:]
+	name := placeholders[len(placeholders)-1]
+	if idx < len(placeholders) {
+		name = placeholders[idx]
+	}
+	name = strings.Trim(name, "[]<>{}.")
+	if name == "" {
+		return "arg"
+	}
+	return name
+}
+
+// splits a completion entry of the form "value\tdescription"
+func parseCandidate(txt string) candidate {
+	value, description, _ := strings.Cut(txt, "\t")
+	return candidate{value: value, description: description}
+}
+
+// returns the proposed values for the next positional arg of the command
+func argCandidates(cmd *cobra.Command, args []string) []candidate {
+	ret := make([]candidate, 0)
+	for _, a := range cmd.ValidArgs {
+		ret = append(ret, parseCandidate(a))
+	}
+	if cmd.ValidArgsFunction != nil {
+		ret = append(ret, completionCandidates(cmd.ValidArgsFunction(cmd, args, ""))...)
+	}
+	return ret
+}
+
+// lets the user select one of the candidates. It returns false if the
+// user wants to enter a value by hand
+func (s *Session) selectCandidate(promptString string, candidates []candidate) (string, bool) {
+	if len(candidates) == 0 {
+		return "", false
+	}
+	options := make([]string, 0, len(candidates))
+	values := make(map[string]string)
+	for _, c := range candidates {
+		label := c.value
+		if c.description != "" {
+			label = fmt.Sprintf("%s (%s)", c.value, c.description)
+		}
+		options = append(options, label)
+		values[label] = c.value
+	}
+	selected, err := s.selectFn()(promptString, options)
+	if err != nil {
+		return "", false
+	}
+	if v, ok := values[selected]; ok {
+		return v, true
+	}
+	return selected, true
+}
+
+// asks for the positional args of the selected command, according to the
+// args validator of the command. The collection continues after the
+// given args. It returns ErrCancelled if the input ends.
+func (s *Session) collectArgs(cmd *cobra.Command, args []string, reader *bufio.Reader) ([]string, error) {
+	if !hasPositionalArgs(cmd) {
+		return args, nil
+	}
+	for {
+		// incomplete args are asked for even if probing finds no valid
+		// continuation, the validator may check the content of the args
+		complete := argsValid(cmd, args)
+		if complete && !moreArgsPossible(cmd, args) {
+			return args, nil
+		}
+		hint := fmt.Sprintf("(optional, leave empty to finish, '%s' to go back)", BACK)
+		if !complete {
+			hint = fmt.Sprintf("(required, '%s' to go back)", BACK)
+		}
+		input, ok := s.askArg(cmd, args, len(args), hint, reader)
+		if !ok {
+			// no more input available
+			return args, ErrCancelled
+		}
+		if input == BACK {
+			if len(args) > 0 {
+				args = args[:len(args)-1]
+			}
+			continue
+		}
+		if input == "" {
+			if complete {
+				return args, nil
+			}
+			s.printWarning(fmt.Sprintf("⚠️  Argument <%s> is required, so input is needed!\n", argName(cmd, len(args))))
+			continue
+		}
+		newArgs := append(slices.Clone(args), input)
+		if !argsReachable(cmd, newArgs) {
+			s.printWarning(fmt.Sprintf("⚠️  Invalid argument: %v\n", validateArgs(cmd, newArgs)))
+			continue
+		}
+		fmt.Fprintf(s.out, "\nSet argument: %s\n", input)
+		args = newArgs
+	}
+}
+
+// asks for the value of the positional arg with the given index, it returns
+// false if no more input is available
+func (s *Session) askArg(cmd *cobra.Command, args []string, idx int, hint string, reader *bufio.Reader) (string, bool) {
+	name := argName(cmd, idx)
+	for {
+		promptString := fmt.Sprintf("[arg %d] select value for <%s>, to enter a new value press ESC: ", idx+1, name)
+		input, fromCandidates := s.selectCandidate(promptString, argCandidates(cmd, args[:idx]))
+		if !fromCandidates {
+			s.printInfo(fmt.Sprintf("\n[arg %d] <%s> %s: ", idx+1, name, hint))
+			var err error
+			input, err = reader.ReadString('\n') // read entire line
+			input = strings.TrimSpace(input)
+			if err != nil && input == "" {
+				return "", false
+			}
+		}
+		if input == HELP || input == HELP2 || input == HELP3 {
+			fmt.Fprintf(s.out, "  Usage: %s\n", cmd.UseLine())
+			continue
+		}
+		return input, true
+	}
+}
+
+// renders the positional args for the program call
+func argsTxt(args []string) string {
+	ret := ""
+	if slices.ContainsFunc(args, func(a string) bool { return strings.HasPrefix(a, "-") }) {
+		// args that look like flags have to follow the double dash
+		ret = " --"
+	}
+	for _, a := range args {
+		ret += " " + escapeValue(a)
+	}
+	return ret
+}
@@ -0,0 +1,182 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+func runWithArgsCmd(t *testing.T, argsCmd *cobra.Command, input string, selectArg func(options []string) (string, error)) *ic0bra.InteractiveResult {
+	root := &cobra.Command{Use: "main"}
+	root.AddCommand(argsCmd)
+	s := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader(input)),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			if promptString == ic0bra.SELECT_SUB_CMD_PROMPT {
+				return argsCmd.Name(), nil
+			}
+			return selectArg(options)
+		}),
+	)
+	res, err := s.Run(root)
+	require.NoError(t, err)
+	return res
+}
+
+func noArgSelection(options []string) (string, error) {
+	return "", ic0bra.ErrCancelled
+}
+
+// accepts only numbers as args
+func portArg(cmd *cobra.Command, args []string) error {
+	for _, a := range args {
+		if _, err := strconv.Atoi(a); err != nil {
+			return fmt.Errorf("invalid port %q", a)
+		}
+	}
+	return nil
+}
+
+func TestCollectArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		cmd          *cobra.Command
+		input        string
+		selectArg    func(options []string) (string, error)
+		expectedArgs []string
+		expectedLine string
+	}{
+		{
+			name:         "exact args",
+			cmd:          &cobra.Command{Use: "get [name]", Args: cobra.ExactArgs(1)},
+			input:        "my name\n\n",
+			selectArg:    noArgSelection,
+			expectedArgs: []string{"my name"},
+			expectedLine: "main get my\\ name",
+		},
+		{
+			name:         "no args",
+			cmd:          &cobra.Command{Use: "list", Args: cobra.NoArgs},
+			input:        "\n",
+			selectArg:    noArgSelection,
+			expectedArgs: []string{},
+			expectedLine: "main list",
+		},
+		{
+			name:         "range args",
+			cmd:          &cobra.Command{Use: "copy <src> [dest]", Args: cobra.RangeArgs(1, 2)},
+			input:        "\na\nb\n\n",
+			selectArg:    noArgSelection,
+			expectedArgs: []string{"a", "b"},
+			expectedLine: "main copy a b",
+		},
+		{
+			name:         "minimum args with optional ones",
+			cmd:          &cobra.Command{Use: "add files...", Args: cobra.MinimumNArgs(2)},
+			input:        "a\n\nb\nc\n\n\n",
+			selectArg:    noArgSelection,
+			expectedArgs: []string{"a", "b", "c"},
+			expectedLine: "main add a b c",
+		},
+		{
+			name:         "args looking like flags",
+			cmd:          &cobra.Command{Use: "calc [value]", Args: cobra.ExactArgs(1)},
+			input:        "-5\n\n",
+			selectArg:    noArgSelection,
+			expectedArgs: []string{"-5"},
+			expectedLine: "main calc -- -5",
+		},
+		{
+			name: "valid args",
+			cmd: &cobra.Command{
+				Use:       "set [level]",
+				Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+				ValidArgs: []string{"low\tthe low level", "high\tthe high level"},
+			},
+			input: "\n",
+			selectArg: func(options []string) (string, error) {
+				return options[1], nil
+			},
+			expectedArgs: []string{"high"},
+			expectedLine: "main set high",
+		},
+		{
+			name: "invalid value entered by hand",
+			cmd: &cobra.Command{
+				Use:       "set [level]",
+				Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+				ValidArgs: []string{"low", "high"},
+			},
+			input:        "medium\nlow\n\n",
+			selectArg:    noArgSelection,
+			expectedArgs: []string{"low"},
+			expectedLine: "main set low",
+		},
+		{
+			name: "validator checking the content",
+			cmd: &cobra.Command{
+				Use:  "open <port>",
+				Args: cobra.MatchAll(cobra.ExactArgs(1), portArg),
+			},
+			input:        "http\n8080\n\n",
+			selectArg:    noArgSelection,
+			expectedArgs: []string{"8080"},
+			expectedLine: "main open 8080",
+		},
+		{
+			name: "optional args checking the content",
+			cmd: &cobra.Command{
+				Use:  "open <port> [ports...]",
+				Args: cobra.MatchAll(cobra.RangeArgs(1, 2), portArg),
+			},
+			input:        "80\n443\n\n",
+			selectArg:    noArgSelection,
+			expectedArgs: []string{"80", "443"},
+			expectedLine: "main open 80 443",
+		},
+		{
+			name: "valid args function",
+			cmd: &cobra.Command{
+				Use:  "show [item]",
+				Args: cobra.ExactArgs(1),
+				ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+					return []string{"first", "second"}, cobra.ShellCompDirectiveNoFileComp
+				},
+			},
+			input: "\n",
+			selectArg: func(options []string) (string, error) {
+				return options[0], nil
+			},
+			expectedArgs: []string{"first"},
+			expectedLine: "main show first",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.cmd.Run = func(cmd *cobra.Command, args []string) {}
+			res := runWithArgsCmd(t, test.cmd, test.input, test.selectArg)
+			assert.Equal(t, test.expectedArgs, res.Args)
+			assert.Equal(t, test.expectedLine, res.CommandLine)
+		})
+	}
+}
+
+func TestCollectArgs_EmptyUse(t *testing.T) {
+	// cobra accepts a root command without Use
+	root := &cobra.Command{Args: cobra.ExactArgs(1), Run: func(cmd *cobra.Command, args []string) {}}
+	res, err := ic0bra.RunInteractive(root,
+		ic0bra.WithInput(strings.NewReader("in.txt\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"in.txt"}, res.Args)
+}
@@ -0,0 +1,82 @@
+package ic0bra
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parses a yes/no answer, the second return value is false if the input
+// is no valid answer
+func parseYesNo(input string) (bool, bool) {
+	switch strings.ToLower(input) {
+	case "yes", "y", "true", "t", "1":
+		return true, true
+	case "no", "n", "false", "f", "0":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+func yesNoTxt(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// YesNo asks for a bool flag with a yes/no question, an empty input keeps
+// the current value of the flag
+func (p *FlagPrompt) YesNo() ([]string, error) {
+	current, _ := strconv.ParseBool(p.Flag.Value.String())
+	for {
+		fmt.Fprintf(p.Out(), "\n[%d/%d] --%s: %s (current %s) [yes|no]: ", p.Pos, p.Total, p.Flag.Name, p.Flag.Usage, yesNoTxt(current))
+		input, err := p.ReadLine()
+		if err != nil {
+			return nil, ErrCancelled
+		}
+		if input == BACK {
+			return nil, ErrBack
+		}
+		if input == "" {
+			if !p.Required {
+				return nil, nil
+			}
+			input = strconv.FormatBool(current)
+		}
+		b, ok := parseYesNo(input)
+		if !ok {
+			p.Warn("wrong input ... only [yes|no|empty] are allowed!\n")
+			continue
+		}
+		fmt.Fprintf(p.Out(), "\nSet value: --%s %s\n", p.Flag.Name, yesNoTxt(b))
+		return []string{strconv.FormatBool(b)}, nil
+	}
+}
+
+// RepeatedYesNo asks for the values of a bool slice flag with yes/no
+// answers until an empty input is given
+func (p *FlagPrompt) RepeatedYesNo() ([]string, error) {
+	values := make([]string, 0)
+	fmt.Fprintf(p.Out(), "\n[%d/%d] --%s %s\nmultiple values possible [yes|no]: ", p.Pos, p.Total, p.Flag.Name, p.Hint)
+	for {
+		input, err := p.ReadLine()
+		if err != nil {
+			return nil, ErrCancelled
+		}
+		if input == BACK {
+			return nil, ErrBack
+		}
+		if input == "" {
+			return values, nil
+		}
+		if b, ok := parseYesNo(input); ok {
+			fmt.Fprintf(p.Out(), "\nSet value: --%s %s\n", p.Flag.Name, yesNoTxt(b))
+			values = append(values, strconv.FormatBool(b))
+		} else {
+			p.Warn("wrong input ... only [yes|no|empty] are allowed!\n")
+		}
+		fmt.Fprintf(p.Out(), "\nnext value [yes|no], empty input to finish: ")
+	}
+}
@@ -0,0 +1,86 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+type boolTestFlags struct {
+	enabled bool
+	force   bool
+	color   string
+	checks  []bool
+}
+
+func newBoolTestRoot(flags *boolTestFlags) *cobra.Command {
+	root := &cobra.Command{Use: "main"}
+	runCmd := &cobra.Command{Use: "run", Args: cobra.NoArgs, Run: func(cmd *cobra.Command, args []string) {}}
+	runCmd.Flags().BoolSliceVar(&flags.checks, "checks", []bool{}, "a bool slice")
+	runCmd.Flags().StringVar(&flags.color, "color", "never", "a flag with NoOptDefVal")
+	runCmd.Flags().Lookup("color").NoOptDefVal = "auto"
+	runCmd.Flags().BoolVar(&flags.enabled, "enabled", true, "a bool defaulting to true")
+	runCmd.Flags().BoolVar(&flags.force, "force", false, "a bool defaulting to false")
+	root.AddCommand(runCmd)
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	return root
+}
+
+func TestBoolFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		selection string
+		expected  string
+		flags     boolTestFlags
+	}{
+		{name: "set", input: "\ny\nno\n\nno\nyes\n", expected: "main run --checks=true --checks=false --enabled=false --force",
+			flags: boolTestFlags{checks: []bool{true, false}, force: true, color: "never"}},
+		{name: "current values kept", input: "\n\n\n\n", expected: "main run",
+			flags: boolTestFlags{checks: []bool{}, enabled: true, color: "never"}},
+		{name: "invalid input", input: "\n\n\nmaybe\nyes\n", expected: "main run --force",
+			flags: boolTestFlags{checks: []bool{}, enabled: true, force: true, color: "never"}},
+		{name: "no opt default", input: "\n\n\n\n", selection: fmt.Sprintf(ic0bra.NOOPT_SET, "auto"), expected: "main run --color",
+			flags: boolTestFlags{checks: []bool{}, enabled: true, color: "auto"}},
+		{name: "no opt other value", input: "\n\nalways\n\n\n", selection: ic0bra.NOOPT_VALUE, expected: "main run --color=always",
+			flags: boolTestFlags{checks: []bool{}, enabled: true, color: "always"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var flags boolTestFlags
+			root := newBoolTestRoot(&flags)
+			res, err := ic0bra.New(
+				ic0bra.WithInput(strings.NewReader(test.input)),
+				ic0bra.WithOutput(&bytes.Buffer{}),
+				ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+					if strings.Contains(promptString, "--color") {
+						if test.selection == "" {
+							return ic0bra.NOOPT_SKIP, nil
+						}
+						return test.selection, nil
+					}
+					return "run", nil
+				}),
+				ic0bra.WithoutConfirm(),
+			).Run(root)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, res.CommandLine)
+			assert.Equal(t, test.flags, flags)
+
+			// cobra parses the rendered program call to the same values
+			var parsed boolTestFlags
+			root = newBoolTestRoot(&parsed)
+			root.SetArgs(strings.Fields(res.CommandLine)[1:])
+			require.NoError(t, root.Execute())
+			assert.Equal(t, test.flags, parsed)
+		})
+	}
+}
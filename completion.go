@@ -0,0 +1,95 @@
+package ic0bra
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// returns the proposed values of the completion function registered for
+// the flag, nil if there is no completion function
+func flagCandidates(cmd *cobra.Command, f *pflag.Flag) []candidate {
+	compFunc, ok := cmd.GetFlagCompletionFunc(f.Name)
+	if !ok {
+		return nil
+	}
+	return completionCandidates(compFunc(cmd, []string{}, ""))
+}
+
+// converts the result of a cobra completion function into candidates, the
+// directives are interpreted the same way the shell completion does
+func completionCandidates(completions []string, directive cobra.ShellCompDirective) []candidate {
+	if directive&cobra.ShellCompDirectiveError != 0 {
+		return nil
+	}
+	if directive&cobra.ShellCompDirectiveFilterFileExt != 0 {
+		// the completions are the allowed file extensions
+		return fileCandidates(".", func(e os.DirEntry) bool {
+			return !e.IsDir() && slices.Contains(completions, strings.TrimPrefix(filepath.Ext(e.Name()), "."))
+		})
+	}
+	if directive&cobra.ShellCompDirectiveFilterDirs != 0 {
+		// an optional completion is the directory to look in
+		dir := "."
+		if len(completions) > 0 {
+			dir = completions[0]
+		}
+		return fileCandidates(dir, func(e os.DirEntry) bool {
+			return e.IsDir()
+		})
+	}
+	ret := make([]candidate, 0)
+	for _, c := range completions {
+		if !strings.HasPrefix(c, activeHelpPrefix) {
+			ret = append(ret, parseCandidate(c))
+		}
+	}
+	if len(ret) == 0 && directive&cobra.ShellCompDirectiveNoFileComp == 0 {
+		// the shell would propose files in this case
+		return fileCandidates(".", func(e os.DirEntry) bool {
+			return true
+		})
+	}
+	return ret
+}
+
+// returns the entries of the directory that match the filter
+func fileCandidates(dir string, filter func(e os.DirEntry) bool) []candidate {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	ret := make([]candidate, 0)
+	for _, e := range entries {
+		if !filter(e) {
+			continue
+		}
+		value := e.Name()
+		if dir != "." {
+			value = filepath.Join(dir, value)
+		}
+		if e.IsDir() {
+			value += string(filepath.Separator)
+		}
+		ret = append(ret, candidate{value: value})
+	}
+	return ret
+}
+
+// offers the candidates of a flag for selection, values contained in
+// ignore are not offered. It returns false if there is nothing to select or
+// the user wants to enter a value by hand
+func (s *Session) selectFlagCandidate(f *pflag.Flag, candidates []candidate, ignore []string, maxFlags, currentFlag int) (string, bool) {
+	toOffer := make([]candidate, 0, len(candidates))
+	for _, c := range candidates {
+		if !slices.Contains(ignore, c.value) {
+			toOffer = append(toOffer, c)
+		}
+	}
+	return s.selectCandidate(fmt.Sprintf("[%d/%d] Select the value for '--%s', to enter a new value press ESC: ", currentFlag, maxFlags, f.Name), toOffer)
+}
@@ -0,0 +1,124 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+// runs the wizard for a command `main deploy` with one flag, selectValue
+// is called for all selections besides the sub command selection
+func runWithCompletion(t *testing.T, initFlag func(cmd *cobra.Command), input string, selectValue func(options []string) (string, error)) *ic0bra.InteractiveResult {
+	root := &cobra.Command{Use: "main"}
+	deployCmd := &cobra.Command{Use: "deploy", Run: func(cmd *cobra.Command, args []string) {}}
+	root.AddCommand(deployCmd)
+	initFlag(deployCmd)
+	s := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader(input)),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			if promptString == ic0bra.SELECT_SUB_CMD_PROMPT {
+				return "deploy", nil
+			}
+			return selectValue(options)
+		}),
+	)
+	res, err := s.Run(root)
+	require.NoError(t, err)
+	return res
+}
+
+func initEnvFlag(directive cobra.ShellCompDirective, completions ...string) func(cmd *cobra.Command) {
+	return func(cmd *cobra.Command) {
+		cmd.Flags().String("env", "", "the environment")
+		cmd.RegisterFlagCompletionFunc("env", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completions, directive
+		})
+	}
+}
+
+func TestFlagCompletion_SelectCandidate(t *testing.T) {
+	var offered []string
+	res := runWithCompletion(t, initEnvFlag(cobra.ShellCompDirectiveNoFileComp, "dev\tdevelopment", "prod\tproduction"), "\n\n", func(options []string) (string, error) {
+		offered = options
+		return options[1], nil
+	})
+	assert.Equal(t, []string{"dev (development)", "prod (production)"}, offered)
+	assert.Equal(t, []string{"prod"}, res.Flags["env"].Values)
+	assert.Equal(t, "main deploy --env prod", res.CommandLine)
+}
+
+func TestFlagCompletion_FreeInputAfterEsc(t *testing.T) {
+	res := runWithCompletion(t, initEnvFlag(cobra.ShellCompDirectiveNoFileComp, "dev", "prod"), "\ntest\n\n", func(options []string) (string, error) {
+		return "", ic0bra.ErrCancelled
+	})
+	assert.Equal(t, []string{"test"}, res.Flags["env"].Values)
+}
+
+func TestFlagCompletion_Error(t *testing.T) {
+	var offered []string
+	res := runWithCompletion(t, initEnvFlag(cobra.ShellCompDirectiveError, "dev"), "\ntest\n\n", func(options []string) (string, error) {
+		offered = options
+		return options[0], nil
+	})
+	assert.Nil(t, offered)
+	assert.Equal(t, []string{"test"}, res.Flags["env"].Values)
+}
+
+func TestFlagCompletion_RepeatedFlag(t *testing.T) {
+	initFlag := func(cmd *cobra.Command) {
+		cmd.Flags().StringSlice("env", []string{}, "the environments")
+		cmd.RegisterFlagCompletionFunc("env", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return []string{"dev", "test", "prod"}, cobra.ShellCompDirectiveNoFileComp
+		})
+	}
+	selections := 0
+	res := runWithCompletion(t, initFlag, "\n\n\n", func(options []string) (string, error) {
+		selections++
+		if selections > 2 {
+			return "", ic0bra.ErrCancelled
+		}
+		return options[len(options)-1], nil
+	})
+	assert.Equal(t, []string{"prod", "test"}, res.Flags["env"].Values)
+	assert.Equal(t, "main deploy --env prod --env test", res.CommandLine)
+}
+
+func TestFlagCompletion_FileFilters(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "a.yaml"), []byte{}, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "b.json"), []byte{}, 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(tmpDir, "conf"), 0700))
+	require.NoError(t, os.Mkdir(filepath.Join(tmpDir, "conf", "sub"), 0700))
+	t.Chdir(tmpDir)
+
+	tests := []struct {
+		name        string
+		directive   cobra.ShellCompDirective
+		completions []string
+		expected    []string
+	}{
+		{name: "file extensions", directive: cobra.ShellCompDirectiveFilterFileExt, completions: []string{"yaml"}, expected: []string{"a.yaml"}},
+		{name: "dirs", directive: cobra.ShellCompDirectiveFilterDirs, expected: []string{"conf" + string(filepath.Separator)}},
+		{name: "dirs in sub dir", directive: cobra.ShellCompDirectiveFilterDirs, completions: []string{"conf"}, expected: []string{filepath.Join("conf", "sub") + string(filepath.Separator)}},
+		{name: "default file completion", directive: cobra.ShellCompDirectiveDefault, expected: []string{"a.yaml", "b.json", "conf" + string(filepath.Separator)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var offered []string
+			runWithCompletion(t, initEnvFlag(test.directive, test.completions...), "\n\n", func(options []string) (string, error) {
+				offered = options
+				return options[0], nil
+			})
+			assert.Equal(t, test.expected, offered)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package ic0bra
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// annotation with the allowed values of a flag
+const ANNOTATION_ENUM = "ic0bra_enum"
+
+// entry of the enum selection to leave an optional flag unset
+const ENUM_SKIP = "(skip)"
+
+// MarkFlagEnum restricts the values of the flag to the given ones. The
+// wizard offers them in a selection, for slice flags in a multi selection,
+// and rejects other values.
+func MarkFlagEnum(fs *pflag.FlagSet, name string, values ...string) error {
+	return fs.SetAnnotation(name, ANNOTATION_ENUM, values)
+}
+
+// returns the allowed values of the flag, nil if the values aren't restricted
+func enumValues(f *pflag.Flag) []string {
+	return f.Annotations[ANNOTATION_ENUM]
+}
+
+// returns an error if the input contains values outside the allowed values
+// of the flag. The input of slice flags can contain several values
+// separated by comma.
+func validateEnum(f *pflag.Flag, input string) error {
+	allowed := enumValues(f)
+	if allowed == nil {
+		return nil
+	}
+	values := []string{input}
+	if isRepeatableFlag(f) && f.Value.Type() != "stringArray" {
+		values = strings.Split(input, ",")
+	}
+	for _, v := range values {
+		if !slices.Contains(allowed, v) {
+			return fmt.Errorf("invalid value %q, allowed are: %s", v, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// Enum lets the user select the value of the flag from the allowed values,
+// for slice flags several values can be selected
+func (p *FlagPrompt) Enum() ([]string, error) {
+	allowed := enumValues(p.Flag)
+	promptString := fmt.Sprintf("[%d/%d] --%s %s: ", p.Pos, p.Total, p.Flag.Name, p.Hint)
+	for {
+		var values []string
+		if isRepeatableFlag(p.Flag) {
+			selected, err := p.s.multiSelectFn()(promptString, allowed, SelectConfig{})
+			if aborted(err) {
+				return nil, ErrBack
+			}
+			if err != nil {
+				return nil, err
+			}
+			values = selected
+		} else {
+			options := slices.Clone(allowed)
+			if !p.Required {
+				options = append(options, ENUM_SKIP)
+			}
+			selected, err := p.Select(promptString, options)
+			if aborted(err) {
+				return nil, ErrBack
+			}
+			if err != nil {
+				return nil, err
+			}
+			if selected != ENUM_SKIP {
+				values = []string{selected}
+			}
+		}
+		if len(values) == 0 && p.Required {
+			p.Warn(fmt.Sprintf("⚠️  Flag %s is required, so input is needed!\n", p.Flag.Name))
+			continue
+		}
+		if err := p.validateAll(values); err != nil {
+			// e.g. an allowed value that doesn't fit to the type of the flag
+			p.Warn(fmt.Sprintf("⚠️  Could not set flag %s: %v\n", p.Flag.Name, err))
+			continue
+		}
+		for _, v := range values {
+			fmt.Fprintf(p.Out(), "\nSet value: --%s %s\n", p.Flag.Name, v)
+		}
+		return values, nil
+	}
+}
+
+func (p *FlagPrompt) validateAll(values []string) error {
+	for _, v := range values {
+		if err := p.Validate(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
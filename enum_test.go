@@ -0,0 +1,122 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+func newEnumTestRoot(t *testing.T) *cobra.Command {
+	root := &cobra.Command{Use: "main"}
+	runCmd := &cobra.Command{Use: "run", Run: func(cmd *cobra.Command, args []string) {}}
+	runCmd.Flags().StringSlice("formats", []string{}, "the output formats")
+	runCmd.Flags().String("level", "info", "the log level")
+	require.NoError(t, ic0bra.MarkFlagEnum(runCmd.Flags(), "formats", "json", "yaml", "text"))
+	require.NoError(t, ic0bra.MarkFlagEnum(runCmd.Flags(), "level", "debug", "info", "warn"))
+	root.AddCommand(runCmd)
+	return root
+}
+
+func TestEnumFlags(t *testing.T) {
+	tests := []struct {
+		name          string
+		selection     string
+		multiSelected []string
+		expected      string
+	}{
+		{name: "selected", selection: "warn", multiSelected: []string{"json", "yaml"}, expected: "main run --formats json --formats yaml --level warn"},
+		{name: "skipped", selection: ic0bra.ENUM_SKIP, multiSelected: []string{}, expected: "main run"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := newEnumTestRoot(t)
+			var offered []string
+			var multiOffered []string
+			res, err := ic0bra.New(
+				ic0bra.WithInput(strings.NewReader("\n")),
+				ic0bra.WithOutput(&bytes.Buffer{}),
+				ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+					if strings.Contains(promptString, "--level") {
+						offered = options
+						return test.selection, nil
+					}
+					return "run", nil
+				}),
+				ic0bra.WithMultiSelector(func(promptString string, options []string, cfg ic0bra.SelectConfig) ([]string, error) {
+					multiOffered = options
+					return test.multiSelected, nil
+				}),
+				ic0bra.WithoutConfirm(),
+			).Run(root)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, res.CommandLine)
+			assert.Equal(t, []string{"debug", "info", "warn", ic0bra.ENUM_SKIP}, offered)
+			assert.Equal(t, []string{"json", "yaml", "text"}, multiOffered)
+		})
+	}
+}
+
+func TestEnumFlags_SelectorError(t *testing.T) {
+	errSelector := errors.New("no terminal")
+	for _, failMulti := range []bool{true, false} {
+		calls := 0
+		// returns the error of the failing selector, which must not be asked again
+		fail := func() error {
+			calls++
+			require.Less(t, calls, 2, "the flag is asked again")
+			return errSelector
+		}
+		_, err := ic0bra.New(
+			ic0bra.WithInput(strings.NewReader("\n")),
+			ic0bra.WithOutput(&bytes.Buffer{}),
+			ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+				if strings.Contains(promptString, "--level") {
+					return "", fail()
+				}
+				return "run", nil
+			}),
+			ic0bra.WithMultiSelector(func(promptString string, options []string, cfg ic0bra.SelectConfig) ([]string, error) {
+				if failMulti {
+					return nil, fail()
+				}
+				return []string{}, nil
+			}),
+			ic0bra.WithoutConfirm(),
+		).Run(newEnumTestRoot(t))
+		assert.ErrorIs(t, err, errSelector)
+	}
+}
+
+func TestEnumFlags_Validation(t *testing.T) {
+	root := newEnumTestRoot(t)
+	text := ic0bra.FlagPrompterFunc(func(p *ic0bra.FlagPrompt) ([]string, error) {
+		return p.Text()
+	})
+	var out bytes.Buffer
+	res, err := ic0bra.New(
+		// the enum flags are asked by text prompts
+		ic0bra.WithFlagPrompter("string", text),
+		ic0bra.WithFlagPrompter("stringSlice", ic0bra.FlagPrompterFunc(func(p *ic0bra.FlagPrompt) ([]string, error) {
+			return p.RepeatedText()
+		})),
+		ic0bra.WithInput(strings.NewReader("\njson,xml\njson,text\n\ntrace\ndebug\n")),
+		ic0bra.WithOutput(&out),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "run", nil
+		}),
+		ic0bra.WithoutConfirm(),
+	).Run(root)
+	require.NoError(t, err)
+	assert.Equal(t, "main run --formats json,text --level debug", res.CommandLine)
+	assert.Contains(t, out.String(), `invalid value "xml", allowed are: json, yaml, text`)
+	assert.Contains(t, out.String(), `invalid value "trace", allowed are: debug, info, warn`)
+
+	assert.Error(t, ic0bra.MarkFlagEnum(root.Flags(), "unknown", "a"))
+}
@@ -0,0 +1,96 @@
+package ic0bra
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// ExecuteInteractive runs the wizard beginning at the given command and
+// executes the selected command with cobra's normal lifecycle, so that
+// hooks, RunE, flag validation and context propagation work as for a call
+// from the command line. It returns the error of the executed command, or
+// ErrCancelled if the user canceled the wizard. Called again by the executed
+// command, it returns ErrReentered instead of starting the wizard again.
+// cmd - cobra command to start the wizard from, usually the root command
+// opts - optional session configuration
+func ExecuteInteractive(cmd *cobra.Command, opts ...Option) error {
+	return New(opts...).ExecuteContext(context.Background(), cmd)
+}
+
+// ExecuteInteractiveWithHistory is the same as ExecuteInteractive, but
+// proposes input for the flags from a history
+// appName - used as entry directory in the user config folder to store the history values
+func ExecuteInteractiveWithHistory(cmd *cobra.Command, appName string, opts ...Option) error {
+	histProvider, err := NewFileHistoryProvider(appName)
+	if err != nil {
+		return err
+	}
+	return ExecuteInteractive(cmd, append([]Option{WithHistoryProvider(histProvider)}, opts...)...)
+}
+
+// ExecuteInteractiveContext is the same as ExecuteInteractive, the context
+// is passed to the executed command
+func ExecuteInteractiveContext(ctx context.Context, cmd *cobra.Command, opts ...Option) error {
+	return New(opts...).ExecuteContext(ctx, cmd)
+}
+
+// Execute runs the wizard and executes the selected command, see
+// ExecuteInteractive
+func (s *Session) Execute(cmd *cobra.Command) error {
+	return s.ExecuteContext(context.Background(), cmd)
+}
+
+// ExecuteContext runs the wizard and executes the selected command with the
+// given context, see ExecuteInteractive
+func (s *Session) ExecuteContext(ctx context.Context, cmd *cobra.Command) error {
+	if executing(cmd) {
+		return ErrReentered
+	}
+	res, err := s.Run(cmd)
+	if err != nil {
+		return err
+	}
+	return res.ExecuteContext(ctx)
+}
+
+// ExecuteContext executes the selected command through the root command of
+// the tree. The collected flags are already set on the flags of the commands,
+// so only the command path and the positional args are passed to cobra.
+// Afterwards the root command reads its args from os.Args again. A canceled
+// result returns ErrCancelled.
+func (r *InteractiveResult) ExecuteContext(ctx context.Context) error {
+	if r.Cancelled || r.Command == nil {
+		return ErrCancelled
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	root := r.Command.Root()
+	root.SetArgs(executionArgs(r.CommandPath, r.Args))
+	defer root.SetArgs(nil)
+	defer root.SetContext(root.Context())
+	return root.ExecuteContext(context.WithValue(ctx, executingKey{}, true))
+}
+
+// marks the context of an execution started by the wizard
+type executingKey struct{}
+
+// true while the wizard executes a command of the tree, cobra passes the
+// context to the root command of every execution
+func executing(cmd *cobra.Command) bool {
+	ctx := cmd.Root().Context()
+	return ctx != nil && ctx.Value(executingKey{}) != nil
+}
+
+// builds the args for the execution by the root command, the first entry of
+// the path is the root command itself
+func executionArgs(path, args []string) []string {
+	ret := slices.Clone(path[1:])
+	if slices.ContainsFunc(args, func(a string) bool { return strings.HasPrefix(a, "-") }) {
+		ret = append(ret, "--")
+	}
+	return append(ret, args...)
+}
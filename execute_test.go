@@ -0,0 +1,172 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+type ctxKey struct{}
+
+func TestExecuteInteractive(t *testing.T) {
+	calls := make([]string, 0)
+	var env string
+	var gotArgs []string
+	var gotCtxValue any
+	errDeploy := errors.New("deploy failed")
+
+	root := &cobra.Command{
+		Use: "main",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			calls = append(calls, "persistentPreRun")
+			return nil
+		},
+	}
+	deployCmd := &cobra.Command{
+		Use:  "deploy [target]",
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			calls = append(calls, "preRun")
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			calls = append(calls, "run")
+			gotArgs = args
+			gotCtxValue = cmd.Context().Value(ctxKey{})
+			return errDeploy
+		},
+		PostRun: func(cmd *cobra.Command, args []string) {
+			calls = append(calls, "postRun")
+		},
+	}
+	deployCmd.Flags().StringVar(&env, "env", "", "the env")
+	deployCmd.MarkFlagRequired("env")
+	root.AddCommand(deployCmd)
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	err := ic0bra.ExecuteInteractiveContext(ctx, root,
+		ic0bra.WithInput(strings.NewReader("\nprod\n-x\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "deploy", nil
+		}),
+	)
+	assert.ErrorIs(t, err, errDeploy)
+	// the error of RunE prevents the call of PostRun
+	assert.Equal(t, []string{"persistentPreRun", "preRun", "run"}, calls)
+	assert.Equal(t, []string{"-x"}, gotArgs)
+	assert.Equal(t, "prod", env)
+	assert.Equal(t, "value", gotCtxValue)
+}
+
+func TestExecuteInteractive_Cancel(t *testing.T) {
+	executed := false
+	root := &cobra.Command{Use: "main"}
+	root.AddCommand(&cobra.Command{Use: "cmd", Run: func(cmd *cobra.Command, args []string) {
+		executed = true
+	}})
+	err := ic0bra.ExecuteInteractive(root,
+		ic0bra.WithInput(strings.NewReader("no\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "cmd", nil
+		}),
+	)
+	assert.ErrorIs(t, err, ic0bra.ErrCancelled)
+	assert.False(t, executed)
+}
+
+func TestExecuteInteractive_ResetsArgs(t *testing.T) {
+	executed := make([]string, 0)
+	run := func(cmd *cobra.Command, args []string) {
+		executed = append(executed, cmd.Name())
+	}
+	root := &cobra.Command{Use: "main"}
+	root.AddCommand(&cobra.Command{Use: "one", Run: run}, &cobra.Command{Use: "two", Run: run})
+	require.NoError(t, ic0bra.ExecuteInteractive(root,
+		ic0bra.WithInput(strings.NewReader("\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "one", nil
+		}),
+	))
+	// a later execution uses the command line again
+	origArgs := os.Args
+	defer func() { os.Args = origArgs }()
+	os.Args = []string{"main", "two"}
+	require.NoError(t, root.Execute())
+	assert.Equal(t, []string{"one", "two"}, executed)
+}
+
+func TestExecuteInteractive_FromRootRun(t *testing.T) {
+	var executed []string
+	root := &cobra.Command{Use: "main"}
+	sub := &cobra.Command{Use: "sub"}
+	sub.AddCommand(&cobra.Command{Use: "leaf", Run: func(cmd *cobra.Command, args []string) {
+		executed = append(executed, cmd.CommandPath())
+	}})
+	root.AddCommand(sub)
+	selections := []string{"sub", "leaf"}
+	root.Run = func(cmd *cobra.Command, args []string) {
+		err := ic0bra.ExecuteInteractive(cmd,
+			ic0bra.WithInput(strings.NewReader("\n")),
+			ic0bra.WithOutput(&bytes.Buffer{}),
+			ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+				ret := selections[0]
+				selections = selections[1:]
+				return ret, nil
+			}),
+		)
+		require.NoError(t, err)
+	}
+	root.SetArgs([]string{})
+	require.NoError(t, root.Execute())
+	assert.Equal(t, []string{"main sub leaf"}, executed)
+}
+
+func TestExecuteInteractive_Reentered(t *testing.T) {
+	calls := 0
+	// a root command without sub commands executes itself
+	root := &cobra.Command{Use: "main", RunE: func(cmd *cobra.Command, args []string) error {
+		calls++
+		require.Less(t, calls, 3, "the wizard was started again")
+		return ic0bra.ExecuteInteractive(cmd,
+			ic0bra.WithInput(strings.NewReader("\n")),
+			ic0bra.WithOutput(&bytes.Buffer{}),
+		)
+	}}
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{})
+	assert.ErrorIs(t, root.Execute(), ic0bra.ErrReentered)
+	assert.Equal(t, 2, calls)
+}
+
+func TestInteractiveResult_ExecuteCancelled(t *testing.T) {
+	root := &cobra.Command{Use: "main"}
+	deployCmd := &cobra.Command{Use: "deploy", Run: func(cmd *cobra.Command, args []string) {}}
+	deployCmd.Flags().String("env", "", "the env")
+	deployCmd.MarkFlagRequired("env")
+	root.AddCommand(deployCmd)
+	// the input ends at the prompt of the flag
+	res, err := ic0bra.RunInteractive(root,
+		ic0bra.WithInput(strings.NewReader("")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "deploy", nil
+		}),
+	)
+	require.ErrorIs(t, err, ic0bra.ErrCancelled)
+	assert.ErrorIs(t, res.ExecuteContext(context.Background()), ic0bra.ErrCancelled)
+}
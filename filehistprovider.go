@@ -10,6 +10,8 @@ import (
 
 type FileHistoryProvider struct {
 	histDir string
+	// used to select from the history, the package default if not set
+	selector SelectFunc
 }
 
 func NewFileHistoryProvider(appName string) (*FileHistoryProvider, error) {
@@ -59,7 +61,11 @@ func (p *FileHistoryProvider) InputFromHist(flagName, txt string, ignoreTxt []st
 	if err != nil {
 		return "", err
 	}
-	return selectionFactory(fmt.Sprintf("[%d/%d] Select from the previous input for '--%s': ", currentFlag, maxFlags, flagName), contentToUse)
+	selectFn := p.selector
+	if selectFn == nil {
+		selectFn = selectionFactory
+	}
+	return selectFn(fmt.Sprintf("[%d/%d] Select from the previous input for '--%s': ", currentFlag, maxFlags, flagName), contentToUse)
 }
 
 func (p *FileHistoryProvider) GetHistFileName(flagName string) string {
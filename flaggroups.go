@@ -0,0 +1,332 @@
+package ic0bra
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// annotations cobra sets for the flags of the groups defined with
+// MarkFlagsRequiredTogether, MarkFlagsOneRequired and MarkFlagsMutuallyExclusive
+const (
+	requiredTogetherAnnotation  = "cobra_annotation_required_if_others_set"
+	oneRequiredAnnotation       = "cobra_annotation_one_required"
+	mutuallyExclusiveAnnotation = "cobra_annotation_mutually_exclusive"
+)
+
+const CHOOSE_FLAG_PROMPT = "Choose one of the flags: "
+
+// option of the "choose one of" step to set none of the flags
+const NO_FLAG = "none"
+
+// a flag group defined with the MarkFlags... functions of cobra
+type flagGroup struct {
+	// the annotation of the group
+	kind  string
+	names []string
+}
+
+func (g flagGroup) key() string {
+	return g.kind + " " + strings.Join(g.names, " ")
+}
+
+func (g flagGroup) flagList() string {
+	return "--" + strings.Join(g.names, ", --")
+}
+
+// a selection in a "choose one of" step
+type flagChoice struct {
+	// name of the chosen flag, empty for none
+	name string
+	// index of the flag the choice was made at
+	madeAt int
+}
+
+// returns the flag groups the given flags belong to. Only the flags in the
+// list are kept as members of the groups.
+func flagGroups(flags []*pflag.Flag) []flagGroup {
+	known := make(map[string]bool)
+	for _, f := range flags {
+		known[f.Name] = true
+	}
+	ret := make([]flagGroup, 0)
+	seen := make(map[string]bool)
+	for _, f := range flags {
+		for _, kind := range []string{requiredTogetherAnnotation, oneRequiredAnnotation, mutuallyExclusiveAnnotation} {
+			for _, group := range f.Annotations[kind] {
+				g := flagGroup{kind: kind, names: slices.DeleteFunc(strings.Fields(group), func(n string) bool {
+					return !known[n]
+				})}
+				if seen[g.key()] || len(g.names) == 0 || (kind != oneRequiredAnnotation && len(g.names) < 2) {
+					continue
+				}
+				seen[g.key()] = true
+				ret = append(ret, g)
+			}
+		}
+	}
+	return ret
+}
+
+// returns the names of the flags in groups that the set flags of the command
+// don't satisfy, e.g. the missing flags of a required-together group
+func unsatisfiedGroupFlags(cmd *cobra.Command) map[string]bool {
+	flags := make([]*pflag.Flag, 0)
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		flags = append(flags, f)
+	})
+	ret := make(map[string]bool)
+	for _, g := range flagGroups(flags) {
+		set := 0
+		for _, n := range g.names {
+			if cmd.Flags().Lookup(n).Changed {
+				set++
+			}
+		}
+		if (g.kind == requiredTogetherAnnotation && set > 0 && set < len(g.names)) ||
+			(g.kind == oneRequiredAnnotation && set == 0) {
+			for _, n := range g.names {
+				ret[n] = true
+			}
+		}
+	}
+	return ret
+}
+
+// orders the flags so that the members of a required-together or mutually
+// exclusive group follow the first of them
+func groupFlagOrder(flags []flagToAsk, groups []flagGroup) []flagToAsk {
+	ret := make([]flagToAsk, 0, len(flags))
+	added := make(map[string]bool)
+	var add func(ftc flagToAsk)
+	add = func(ftc flagToAsk) {
+		if added[ftc.flag.Name] {
+			return
+		}
+		added[ftc.flag.Name] = true
+		ret = append(ret, ftc)
+		for _, g := range groups {
+			if g.kind == oneRequiredAnnotation || !slices.Contains(g.names, ftc.flag.Name) {
+				continue
+			}
+			for _, n := range g.names {
+				i := slices.IndexFunc(flags, func(other flagToAsk) bool { return other.flag.Name == n })
+				if i >= 0 && flags[i].global == ftc.global {
+					add(flags[i])
+				}
+			}
+		}
+	}
+	for _, ftc := range flags {
+		add(ftc)
+	}
+	return ret
+}
+
+// returns the index of the flag to ask for with the given name, or -1
+func (fc *flagCollector) index(name string) int {
+	return slices.IndexFunc(fc.flags, func(ftc flagToAsk) bool { return ftc.flag.Name == name })
+}
+
+// returns true if the flag is preset or has staged input
+func (fc *flagCollector) hasValue(name string) bool {
+	if slices.ContainsFunc(fc.preset, func(cf collectedFlag) bool { return cf.flag.Name == name }) {
+		return true
+	}
+	i := fc.index(name)
+	return i >= 0 && len(fc.collected[i]) > 0
+}
+
+func (fc *flagCollector) anySet(g flagGroup) bool {
+	return slices.ContainsFunc(g.names, fc.hasValue)
+}
+
+// returns the groups of the given kind the flag belongs to
+func (fc *flagCollector) groupsOf(kind, name string) []flagGroup {
+	ret := make([]flagGroup, 0)
+	for _, g := range fc.groups {
+		if g.kind == kind && slices.Contains(g.names, name) {
+			ret = append(ret, g)
+		}
+	}
+	return ret
+}
+
+// returns true if the flag is required, a flag of a required-together
+// group it belongs to is set or it was chosen for a one-required group
+func (fc *flagCollector) isRequired(i int) bool {
+	name := fc.flags[i].flag.Name
+	if isFlagRequired(fc.flags[i].flag) || name == fc.forced {
+		return true
+	}
+	for _, g := range fc.groupsOf(requiredTogetherAnnotation, name) {
+		for _, n := range g.names {
+			if n != name && fc.hasValue(n) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// returns true if the flag isn't asked for, because another flag of a
+// mutually exclusive group is set or was chosen
+func (fc *flagCollector) skipped(i int) bool {
+	name := fc.flags[i].flag.Name
+	for _, g := range fc.groupsOf(mutuallyExclusiveAnnotation, name) {
+		for _, n := range g.names {
+			if n != name && fc.hasValue(n) {
+				return true
+			}
+		}
+		if c, ok := fc.choices[g.key()]; ok && c.name != name {
+			return true
+		}
+	}
+	return false
+}
+
+// lets the user choose one flag of the mutually exclusive groups of the
+// flag with the given index, before the first flag of a group is asked for.
+// The candidates are the flags in the given indexes.
+func (fc *flagCollector) chooseExclusive(i int, indexes []int) error {
+	name := fc.flags[i].flag.Name
+	for _, g := range fc.groupsOf(mutuallyExclusiveAnnotation, name) {
+		if _, ok := fc.choices[g.key()]; ok || fc.anySet(g) {
+			continue
+		}
+		candidates := make([]string, 0)
+		for _, n := range g.names {
+			if j := fc.index(n); j >= 0 && slices.Contains(indexes, j) {
+				candidates = append(candidates, n)
+			}
+		}
+		if len(candidates) < 2 {
+			continue
+		}
+		chosen, err := fc.chooseFlag(candidates, !fc.oneOfRequired(g))
+		if aborted(err) {
+			return ErrBack
+		}
+		if err != nil {
+			return err
+		}
+		fc.choices[g.key()] = flagChoice{name: chosen, madeAt: i}
+	}
+	return nil
+}
+
+// returns true if one of the flags of the group has to be set
+func (fc *flagCollector) oneOfRequired(g flagGroup) bool {
+	for _, n := range g.names {
+		if i := fc.index(n); i >= 0 && isFlagRequired(fc.flags[i].flag) {
+			return true
+		}
+	}
+	return slices.ContainsFunc(fc.groups, func(other flagGroup) bool {
+		return other.kind == oneRequiredAnnotation && !slices.ContainsFunc(other.names, func(n string) bool {
+			return !slices.Contains(g.names, n)
+		})
+	})
+}
+
+// lets the user select one of the flags, it returns the chosen name or an
+// empty string for none
+func (fc *flagCollector) chooseFlag(names []string, noneAllowed bool) (string, error) {
+	options := make([]string, 0, len(names)+1)
+	for _, n := range names {
+		options = append(options, "--"+n)
+	}
+	if noneAllowed {
+		options = append(options, NO_FLAG)
+	}
+	selected, err := fc.s.selectFn()(CHOOSE_FLAG_PROMPT, options)
+	if err != nil {
+		return "", err
+	}
+	if selected == NO_FLAG {
+		return "", nil
+	}
+	chosen := strings.TrimPrefix(selected, "--")
+	if !slices.Contains(names, chosen) {
+		return "", fmt.Errorf("unknown flag: %s", selected)
+	}
+	return chosen, nil
+}
+
+// drops the choices made at the flag with the given index
+func (fc *flagCollector) dropChoices(i int) {
+	for key, c := range fc.choices {
+		if c.madeAt == i {
+			delete(fc.choices, key)
+		}
+	}
+}
+
+// asks for the flags that are still missing to satisfy the required-together
+// and the one-required groups
+func (fc *flagCollector) completeGroups() error {
+	for _, g := range fc.groups {
+		switch g.kind {
+		case requiredTogetherAnnotation:
+			if !fc.anySet(g) {
+				continue
+			}
+			for _, n := range g.names {
+				if i := fc.index(n); i >= 0 && !fc.hasValue(n) {
+					fc.s.printWarning(fmt.Sprintf("\n⚠️  The flags %s have to be set together\n", g.flagList()))
+					if err := fc.askAgain(i, false); err != nil {
+						return err
+					}
+				}
+			}
+		case oneRequiredAnnotation:
+			if fc.anySet(g) {
+				continue
+			}
+			candidates := slices.DeleteFunc(slices.Clone(g.names), func(n string) bool {
+				return fc.index(n) < 0 || fc.skipped(fc.index(n))
+			})
+			if len(candidates) == 0 {
+				continue
+			}
+			fc.s.printWarning(fmt.Sprintf("\n⚠️  One of the flags %s is required\n", g.flagList()))
+			chosen := candidates[0]
+			if len(candidates) > 1 {
+				var err error
+				chosen, err = fc.chooseFlag(candidates, false)
+				if aborted(err) {
+					// the group can't stay unsatisfied
+					return ErrCancelled
+				}
+				if err != nil {
+					return err
+				}
+			}
+			if err := fc.askAgain(fc.index(chosen), true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// asks for the flag outside of the normal order, there is no previous flag
+// to return to
+func (fc *flagCollector) askAgain(i int, forceRequired bool) error {
+	if forceRequired {
+		fc.forced = fc.flags[i].flag.Name
+		defer func() { fc.forced = "" }()
+	}
+	for {
+		err := fc.ask(i, 1, 1)
+		if !errors.Is(err, ErrBack) {
+			return err
+		}
+		fc.s.printWarning("⚠️  This is already the first flag\n")
+	}
+}
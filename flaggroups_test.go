@@ -0,0 +1,163 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+// builds `main run` with the given flags, the groups are defined by the
+// mark function
+func newFlagGroupTestRoot(mark func(cmd *cobra.Command), flags ...string) (*cobra.Command, *cobra.Command) {
+	root := &cobra.Command{Use: "main"}
+	runCmd := &cobra.Command{Use: "run", Run: func(cmd *cobra.Command, args []string) {}}
+	for _, f := range flags {
+		runCmd.Flags().String(f, "", "the "+f)
+	}
+	mark(runCmd)
+	root.AddCommand(runCmd)
+	return root, runCmd
+}
+
+// runs the wizard for `main run`, the selector answers with the given
+// selections after the command selection
+func runFlagGroupTest(t *testing.T, root *cobra.Command, input string, selections ...string) (*ic0bra.InteractiveResult, [][]string) {
+	offered := make([][]string, 0)
+	selections = append([]string{"run"}, selections...)
+	res, err := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader(input)),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			offered = append(offered, options)
+			require.NotEmpty(t, selections, "unexpected selection: %s", promptString)
+			selected := selections[0]
+			selections = selections[1:]
+			return selected, nil
+		}),
+	).Run(root)
+	require.NoError(t, err)
+	return res, offered[1:]
+}
+
+func TestFlagGroups_MutuallyExclusive(t *testing.T) {
+	root, _ := newFlagGroupTestRoot(func(cmd *cobra.Command) {
+		cmd.MarkFlagsMutuallyExclusive("file", "url")
+	}, "file", "name", "url")
+	res, offered := runFlagGroupTest(t, root, "\nhttp://x\nn1\n\n", "--url")
+	assert.Equal(t, "main run --url http://x --name n1", res.CommandLine)
+	assert.Equal(t, [][]string{{"--file", "--url", ic0bra.NO_FLAG}}, offered)
+
+	root, _ = newFlagGroupTestRoot(func(cmd *cobra.Command) {
+		cmd.MarkFlagsMutuallyExclusive("file", "url")
+	}, "file", "name", "url")
+	res, _ = runFlagGroupTest(t, root, "\nn1\n\n", ic0bra.NO_FLAG)
+	assert.Equal(t, "main run --name n1", res.CommandLine)
+}
+
+func TestFlagGroups_MutuallyExclusiveOneRequired(t *testing.T) {
+	root, _ := newFlagGroupTestRoot(func(cmd *cobra.Command) {
+		cmd.MarkFlagsMutuallyExclusive("file", "url")
+		cmd.MarkFlagsOneRequired("file", "url")
+	}, "file", "url")
+	// the first back returns to the choice
+	res, offered := runFlagGroupTest(t, root, "\n<\nin.txt\n\n", "--url", "--file")
+	assert.Equal(t, "main run --file in.txt", res.CommandLine)
+	assert.Equal(t, [][]string{{"--file", "--url"}, {"--file", "--url"}}, offered)
+}
+
+func TestFlagGroups_SelectorError(t *testing.T) {
+	root, _ := newFlagGroupTestRoot(func(cmd *cobra.Command) {
+		cmd.MarkFlagsMutuallyExclusive("file", "url")
+	}, "file", "url")
+	errSelector := errors.New("no terminal")
+	calls := 0
+	_, err := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			if promptString == ic0bra.SELECT_SUB_CMD_PROMPT {
+				return "run", nil
+			}
+			calls++
+			require.Less(t, calls, 2, "the choice is asked again")
+			return "", errSelector
+		}),
+	).Run(root)
+	assert.ErrorIs(t, err, errSelector)
+}
+
+func TestFlagGroups_RequiredTogether(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		// the password is asked again after the user was set
+		{name: "second set", input: "\n\nbob\nsecret\n\n", expected: "main run --password secret --user bob"},
+		// the user is required after the password was set
+		{name: "first set", input: "\nsecret\n\nbob\n\n", expected: "main run --password secret --user bob"},
+		{name: "none set", input: "\n\n\n\n", expected: "main run"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, _ := newFlagGroupTestRoot(func(cmd *cobra.Command) {
+				cmd.MarkFlagsRequiredTogether("user", "password")
+			}, "password", "user")
+			res, _ := runFlagGroupTest(t, root, test.input)
+			assert.Equal(t, test.expected, res.CommandLine)
+		})
+	}
+}
+
+func TestFlagGroups_OneRequired(t *testing.T) {
+	root, _ := newFlagGroupTestRoot(func(cmd *cobra.Command) {
+		cmd.MarkFlagsOneRequired("id", "name")
+	}, "id", "name")
+	res, offered := runFlagGroupTest(t, root, "\n\n\n\nn1\n\n", "--name")
+	assert.Equal(t, "main run --name n1", res.CommandLine)
+	assert.Equal(t, [][]string{{"--id", "--name"}}, offered)
+}
+
+func TestFlagGroups_OneRequiredAborted(t *testing.T) {
+	root, _ := newFlagGroupTestRoot(func(cmd *cobra.Command) {
+		cmd.MarkFlagsOneRequired("id", "name")
+	}, "id", "name")
+	res, err := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\n\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			if promptString == ic0bra.SELECT_SUB_CMD_PROMPT {
+				return "run", nil
+			}
+			return "", ic0bra.ErrCancelled
+		}),
+	).Run(root)
+	assert.ErrorIs(t, err, ic0bra.ErrCancelled)
+	assert.True(t, res.Cancelled)
+}
+
+func TestFlagGroups_Recovery(t *testing.T) {
+	var user, password string
+	root, runCmd := newFlagGroupTestRoot(func(cmd *cobra.Command) {})
+	runCmd.Flags().StringVar(&user, "user", "", "the user")
+	runCmd.Flags().StringVar(&password, "password", "", "the password")
+	runCmd.Flags().String("comment", "", "not asked for")
+	runCmd.MarkFlagsRequiredTogether("user", "password")
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	require.NoError(t, ic0bra.Install(root, ic0bra.WithRecovery(), ic0bra.WithSessionOptions(
+		ic0bra.WithInput(strings.NewReader("\nsecret\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+	)))
+	root.SetArgs([]string{"run", "--user", "bob"})
+	require.NoError(t, ic0bra.Execute(root))
+	assert.Equal(t, "bob", user)
+	assert.Equal(t, "secret", password)
+}
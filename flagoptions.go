@@ -0,0 +1,50 @@
+package ic0bra
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// WithHiddenFlags asks for hidden flags too. Without the option they are
+// skipped, except they are required.
+func WithHiddenFlags() Option {
+	return func(s *Session) {
+		s.hiddenFlags = true
+	}
+}
+
+// WithDeprecatedFlags asks for deprecated flags and shows their deprecation
+// message. Without the option they are skipped, except they are required.
+func WithDeprecatedFlags() Option {
+	return func(s *Session) {
+		s.deprecatedFlags = true
+	}
+}
+
+// WithShorthands renders the flags with a shorthand in the resulting program
+// call in the short form, e.g. `-n value`. Deprecated shorthands are not used.
+func WithShorthands() Option {
+	return func(s *Session) {
+		s.shorthands = true
+	}
+}
+
+// returns true if the flag isn't asked for in the session
+func (s *Session) skipFlag(f *pflag.Flag) bool {
+	if isFlagRequired(f) {
+		return false
+	}
+	if f.Deprecated != "" {
+		// pflag hides the deprecated flags too
+		return !s.deprecatedFlags
+	}
+	return f.Hidden && !s.hiddenFlags
+}
+
+// shows the deprecation message before a deprecated flag is asked for
+func (s *Session) printDeprecation(f *pflag.Flag) {
+	if f.Deprecated != "" {
+		s.printWarning(fmt.Sprintf("\n⚠️  Flag --%s has been deprecated, %s\n", f.Name, f.Deprecated))
+	}
+}
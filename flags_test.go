@@ -0,0 +1,93 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+func TestFlags_InheritedFlagsOnce(t *testing.T) {
+	root := &cobra.Command{Use: "main"}
+	root.PersistentFlags().Bool("verbose", false, "a global flag")
+	root.Flags().String("rootonly", "", "only valid for the root")
+	clusterCmd := &cobra.Command{Use: "cluster"}
+	clusterCmd.PersistentFlags().String("region", "", "the region")
+	nodeCmd := &cobra.Command{Use: "node", Run: func(cmd *cobra.Command, args []string) {}}
+	nodeCmd.Flags().String("name", "", "the node name")
+	clusterCmd.AddCommand(nodeCmd)
+	root.AddCommand(clusterCmd)
+
+	selections := []string{"cluster", "node"}
+	for range 2 {
+		var out bytes.Buffer
+		s := ic0bra.New(
+			ic0bra.WithInput(strings.NewReader("\nn1\neu\ntrue\n\n")),
+			ic0bra.WithOutput(&out),
+			ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+				selected := selections[0]
+				selections = append(selections[1:], selected)
+				return selected, nil
+			}),
+		)
+		res, err := s.Run(root)
+		require.NoError(t, err)
+		assert.Equal(t, "main cluster node --name n1 --region eu --verbose", res.CommandLine)
+		txt := out.String()
+		for _, prompt := range []string{"[1/3] --name", "[2/3] --region", "[3/3] --verbose"} {
+			assert.Equal(t, 1, strings.Count(txt, prompt), prompt)
+		}
+		assert.NotContains(t, txt, "--rootonly")
+		assert.Less(t, strings.Index(txt, ic0bra.COMMAND_FLAGS_HEADER), strings.Index(txt, "--name"))
+		assert.Less(t, strings.Index(txt, ic0bra.GLOBAL_FLAGS_HEADER), strings.Index(txt, "--region"))
+		ic0bra.ResetFlags(nodeCmd)
+	}
+}
+
+func TestFlags_HiddenAndDeprecated(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []ic0bra.Option
+		input    string
+		expected string
+		output   string
+	}{
+		{name: "skipped", input: "\nb\nr\n\n", expected: "main run --name b --token r"},
+		{name: "hidden", opts: []ic0bra.Option{ic0bra.WithHiddenFlags()}, input: "\nd\nb\nr\n\n", expected: "main run --debug d --name b --token r"},
+		{name: "deprecated", opts: []ic0bra.Option{ic0bra.WithDeprecatedFlags()}, input: "\nb\no\nr\n\n", expected: "main run --name b --old o --token r", output: "Flag --old has been deprecated, use --name instead"},
+		{name: "shorthands", opts: []ic0bra.Option{ic0bra.WithShorthands()}, input: "\nb\nr\n\n", expected: "main run --name b -t r"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := &cobra.Command{Use: "main"}
+			runCmd := &cobra.Command{Use: "run", Run: func(cmd *cobra.Command, args []string) {}}
+			runCmd.Flags().StringP("name", "n", "", "the name")
+			runCmd.Flags().String("debug", "", "a hidden flag")
+			runCmd.Flags().String("old", "", "a deprecated flag")
+			runCmd.Flags().StringP("token", "t", "", "a required hidden flag")
+			runCmd.Flags().MarkHidden("debug")
+			runCmd.Flags().MarkHidden("token")
+			runCmd.Flags().MarkDeprecated("old", "use --name instead")
+			runCmd.Flags().MarkShorthandDeprecated("name", "use --name")
+			runCmd.MarkFlagRequired("token")
+			root.AddCommand(runCmd)
+			var out bytes.Buffer
+			opts := append([]ic0bra.Option{
+				ic0bra.WithInput(strings.NewReader(test.input)),
+				ic0bra.WithOutput(&out),
+				ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+					return "run", nil
+				}),
+			}, test.opts...)
+			res, err := ic0bra.New(opts...).Run(root)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, res.CommandLine)
+			assert.Contains(t, out.String(), test.output)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package ic0bra
+
+import (
+	"encoding/csv"
+	"net"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const scratchFlagName = "scratch"
+
+// constructors for empty values of the flag types pflag provides, they are
+// used to validate input without touching the flags of the command
+var scratchValueFactories = map[string]func(fs *pflag.FlagSet){
+	"string":         func(fs *pflag.FlagSet) { fs.String(scratchFlagName, "", "") },
+	"bool":           func(fs *pflag.FlagSet) { fs.Bool(scratchFlagName, false, "") },
+	"int":            func(fs *pflag.FlagSet) { fs.Int(scratchFlagName, 0, "") },
+	"int8":           func(fs *pflag.FlagSet) { fs.Int8(scratchFlagName, 0, "") },
+	"int16":          func(fs *pflag.FlagSet) { fs.Int16(scratchFlagName, 0, "") },
+	"int32":          func(fs *pflag.FlagSet) { fs.Int32(scratchFlagName, 0, "") },
+	"int64":          func(fs *pflag.FlagSet) { fs.Int64(scratchFlagName, 0, "") },
+	"uint":           func(fs *pflag.FlagSet) { fs.Uint(scratchFlagName, 0, "") },
+	"uint8":          func(fs *pflag.FlagSet) { fs.Uint8(scratchFlagName, 0, "") },
+	"uint16":         func(fs *pflag.FlagSet) { fs.Uint16(scratchFlagName, 0, "") },
+	"uint32":         func(fs *pflag.FlagSet) { fs.Uint32(scratchFlagName, 0, "") },
+	"uint64":         func(fs *pflag.FlagSet) { fs.Uint64(scratchFlagName, 0, "") },
+	"float32":        func(fs *pflag.FlagSet) { fs.Float32(scratchFlagName, 0, "") },
+	"float64":        func(fs *pflag.FlagSet) { fs.Float64(scratchFlagName, 0, "") },
+	"duration":       func(fs *pflag.FlagSet) { fs.Duration(scratchFlagName, 0, "") },
+	"count":          func(fs *pflag.FlagSet) { fs.Count(scratchFlagName, "") },
+	"ip":             func(fs *pflag.FlagSet) { fs.IP(scratchFlagName, nil, "") },
+	"ipMask":         func(fs *pflag.FlagSet) { fs.IPMask(scratchFlagName, nil, "") },
+	"ipNet":          func(fs *pflag.FlagSet) { fs.IPNet(scratchFlagName, net.IPNet{}, "") },
+	"bytesHex":       func(fs *pflag.FlagSet) { fs.BytesHex(scratchFlagName, nil, "") },
+	"bytesBase64":    func(fs *pflag.FlagSet) { fs.BytesBase64(scratchFlagName, nil, "") },
+	"stringSlice":    func(fs *pflag.FlagSet) { fs.StringSlice(scratchFlagName, nil, "") },
+	"stringArray":    func(fs *pflag.FlagSet) { fs.StringArray(scratchFlagName, nil, "") },
+	"intSlice":       func(fs *pflag.FlagSet) { fs.IntSlice(scratchFlagName, nil, "") },
+	"int32Slice":     func(fs *pflag.FlagSet) { fs.Int32Slice(scratchFlagName, nil, "") },
+	"int64Slice":     func(fs *pflag.FlagSet) { fs.Int64Slice(scratchFlagName, nil, "") },
+	"uintSlice":      func(fs *pflag.FlagSet) { fs.UintSlice(scratchFlagName, nil, "") },
+	"boolSlice":      func(fs *pflag.FlagSet) { fs.BoolSlice(scratchFlagName, nil, "") },
+	"float32Slice":   func(fs *pflag.FlagSet) { fs.Float32Slice(scratchFlagName, nil, "") },
+	"float64Slice":   func(fs *pflag.FlagSet) { fs.Float64Slice(scratchFlagName, nil, "") },
+	"durationSlice":  func(fs *pflag.FlagSet) { fs.DurationSlice(scratchFlagName, nil, "") },
+	"ipSlice":        func(fs *pflag.FlagSet) { fs.IPSlice(scratchFlagName, nil, "") },
+	"stringToString": func(fs *pflag.FlagSet) { fs.StringToString(scratchFlagName, nil, "") },
+	"stringToInt":    func(fs *pflag.FlagSet) { fs.StringToInt(scratchFlagName, nil, "") },
+	"stringToInt64":  func(fs *pflag.FlagSet) { fs.StringToInt64(scratchFlagName, nil, "") },
+}
+
+// returns an empty value of the same type as the flag, nil if the type is
+// not known
+func newScratchValue(f *pflag.Flag) pflag.Value {
+	factory, ok := scratchValueFactories[f.Value.Type()]
+	if !ok {
+		return nil
+	}
+	fs := pflag.NewFlagSet(scratchFlagName, pflag.ContinueOnError)
+	factory(fs)
+	return fs.Lookup(scratchFlagName).Value
+}
+
+// checks if the input is a valid value for the flag without changing the flag
+func validateFlagValue(f *pflag.Flag, input string) error {
+	if err := validateEnum(f, input); err != nil {
+		return err
+	}
+	if scratch := newScratchValue(f); scratch != nil {
+		return scratch.Set(input)
+	}
+	return validateCustomValue(f.Value, input)
+}
+
+// validates the input for a custom value type on a new zero value of the
+// type. If the zero value can't be used, e.g. because it wraps a pointer to
+// the variable of the flag, the input is accepted and checked when the flag
+// is set.
+func validateCustomValue(v pflag.Value, input string) (err error) {
+	t := reflect.TypeOf(v)
+	if t.Kind() != reflect.Pointer {
+		return nil
+	}
+	fresh, ok := reflect.New(t.Elem()).Interface().(pflag.Value)
+	if !ok {
+		return nil
+	}
+	defer func() {
+		if recover() != nil {
+			err = nil
+		}
+	}()
+	return fresh.Set(input)
+}
+
+// sets the values on the flag. Slice values replace the current content of
+// the flag, so that repeated runs don't accumulate values. The entries of
+// map flags are added to the map, pflag provides no way to remove them.
+func applyFlagValues(fs *pflag.FlagSet, f *pflag.Flag, values []string) error {
+	scratch := newScratchValue(f)
+	sv, isSlice := f.Value.(pflag.SliceValue)
+	if scratchSlice, ok := scratch.(pflag.SliceValue); ok && isSlice {
+		for _, v := range values {
+			if err := scratch.Set(v); err != nil {
+				return err
+			}
+		}
+		if err := sv.Replace(scratchSlice.GetSlice()); err != nil {
+			return err
+		}
+		f.Changed = true
+		return nil
+	}
+	for _, v := range values {
+		if err := fs.Set(f.Name, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returns the current values of a flag
+func flagValues(f *pflag.Flag) []string {
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		return slices.Clone(sv.GetSlice())
+	}
+	if isMapFlag(f) {
+		return mapEntries(f.Value.String())
+	}
+	return []string{f.Value.String()}
+}
+
+// ResetFlags sets the flags of the command and its parents back to their
+// default values and marks them as not changed. It can be used to start a
+// new interactive run with the same command tree. Map flags can't be reset,
+// pflag provides no way to remove their entries, so they keep their values.
+func ResetFlags(cmd *cobra.Command) {
+	for c := cmd; c != nil; c = c.Parent() {
+		c.Flags().VisitAll(resetFlag)
+		c.PersistentFlags().VisitAll(resetFlag)
+	}
+}
+
+func resetFlag(f *pflag.Flag) {
+	if isMapFlag(f) {
+		return
+	}
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		sv.Replace(listValues(f.DefValue))
+	} else {
+		f.Value.Set(f.DefValue)
+	}
+	f.Changed = false
+}
+
+// parses the value of a slice or map flag, pflag renders it like `[a,b]`
+func listValues(value string) []string {
+	inner := strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	if inner == "" {
+		return []string{}
+	}
+	ret, err := csv.NewReader(strings.NewReader(inner)).Read()
+	if err != nil {
+		return strings.Split(inner, ",")
+	}
+	return ret
+}
+
+// state of a flag, used to restore the flag after a failed change
+type flagSnapshot struct {
+	changed bool
+	value   string
+	values  []string
+}
+
+func takeFlagSnapshot(f *pflag.Flag) flagSnapshot {
+	ret := flagSnapshot{
+		changed: f.Changed,
+		value:   f.Value.String(),
+	}
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		ret.values = slices.Clone(sv.GetSlice())
+	}
+	return ret
+}
+
+// restores the flag, besides map flags whose entries can't be removed
+func (fs flagSnapshot) restore(f *pflag.Flag) {
+	if isMapFlag(f) {
+		return
+	}
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		sv.Replace(fs.values)
+	} else {
+		f.Value.Set(fs.value)
+	}
+	f.Changed = fs.changed
+}
@@ -0,0 +1,132 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+func newStagingTestRoot(names *[]string, env *string) *cobra.Command {
+	root := &cobra.Command{Use: "main"}
+	cmd := &cobra.Command{Use: "cmd", Run: func(cmd *cobra.Command, args []string) {}}
+	cmd.Flags().StringVar(env, "env", "dev", "the env")
+	cmd.Flags().StringSliceVar(names, "name", []string{"a"}, "the names")
+	root.AddCommand(cmd)
+	return root
+}
+
+func runStagingTest(root *cobra.Command, input string) (*ic0bra.InteractiveResult, error) {
+	s := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader(input)),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "cmd", nil
+		}),
+	)
+	return s.Run(root)
+}
+
+func TestStaging_CancelKeepsFlags(t *testing.T) {
+	var names []string
+	var env string
+	root := newStagingTestRoot(&names, &env)
+	res, err := runStagingTest(root, "\nprod\nb\nc\n\nno\n")
+	assert.ErrorIs(t, err, ic0bra.ErrCancelled)
+	assert.Equal(t, []string{"b", "c"}, res.Flags["name"].Values)
+	assert.Equal(t, "dev", env)
+	assert.Equal(t, []string{"a"}, names)
+	assert.False(t, res.Command.Flags().Changed("env"))
+	assert.False(t, res.Command.Flags().Changed("name"))
+}
+
+func TestStaging_InvalidValue(t *testing.T) {
+	root := &cobra.Command{Use: "main"}
+	cmd := &cobra.Command{Use: "cmd", Run: func(cmd *cobra.Command, args []string) {}}
+	count := cmd.Flags().Int("count", 0, "the count")
+	root.AddCommand(cmd)
+	res, err := runStagingTest(root, "\nabc\n5\n\n")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5"}, res.Flags["count"].Values)
+	assert.Equal(t, 5, *count)
+}
+
+// custom pflag.Value that appends each value
+type appendValue struct {
+	values []string
+}
+
+func (v *appendValue) String() string { return "[" + strings.Join(v.values, " ") + "]" }
+func (v *appendValue) Type() string   { return "append" }
+func (v *appendValue) Set(s string) error {
+	if s == "" {
+		return errors.New("empty value")
+	}
+	v.values = append(v.values, s)
+	return nil
+}
+
+func TestStaging_CustomValue(t *testing.T) {
+	root := &cobra.Command{Use: "main"}
+	cmd := &cobra.Command{Use: "cmd", Run: func(cmd *cobra.Command, args []string) {}}
+	value := &appendValue{values: []string{"base"}}
+	cmd.Flags().Var(value, "tag", "the tags")
+	root.AddCommand(cmd)
+	s := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\nx\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "cmd", nil
+		}),
+		ic0bra.WithConfirm(func(string) bool {
+			// the input is validated without setting the flag
+			assert.Equal(t, []string{"base"}, value.values)
+			return true
+		}),
+	)
+	res, err := s.Run(root)
+	require.NoError(t, err)
+	assert.Equal(t, "main cmd --tag x", res.CommandLine)
+	assert.Equal(t, []string{"base", "x"}, value.values)
+}
+
+func TestStaging_RepeatedRuns(t *testing.T) {
+	var names []string
+	var env string
+	root := newStagingTestRoot(&names, &env)
+	for range 2 {
+		res, err := runStagingTest(root, "\nprod\nb\nc\n\n\n")
+		require.NoError(t, err)
+		assert.Equal(t, "prod", env)
+		assert.Equal(t, []string{"b", "c"}, names)
+		assert.True(t, res.Command.Flags().Changed("name"))
+	}
+}
+
+func TestResetFlags(t *testing.T) {
+	var names []string
+	var env string
+	root := newStagingTestRoot(&names, &env)
+	res, err := runStagingTest(root, "\nprod\nb\n\n\n")
+	require.NoError(t, err)
+	require.Equal(t, "prod", env)
+
+	res.ResetFlags()
+	assert.Equal(t, "dev", env)
+	assert.Equal(t, []string{"a"}, names)
+	assert.False(t, res.Command.Flags().Changed("env"))
+	assert.False(t, res.Command.Flags().Changed("name"))
+
+	// a flag set of the root is reset by the sub command too
+	verbose := root.PersistentFlags().Bool("verbose", false, "verbose output")
+	require.NoError(t, root.PersistentFlags().Set("verbose", "true"))
+	ic0bra.ResetFlags(res.Command)
+	assert.False(t, *verbose)
+	assert.False(t, root.PersistentFlags().Changed("verbose"))
+}
@@ -0,0 +1,403 @@
+package ic0bra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// name of the flag that launches the wizard
+const INTERACTIVE_FLAG = "interactive"
+
+// name of the sub command that launches the wizard
+const INTERACTIVE_CMD = "interactive"
+
+// marks the flags and commands added by Install, they are not offered in
+// the wizard
+const internalAnnotation = "ic0bra_internal"
+
+// InstallOption configures Install
+type InstallOption func(*installConfig)
+
+type installConfig struct {
+	flag        bool
+	command     bool
+	recovery    bool
+	fallback    bool
+	sessionOpts []Option
+	// the wizard to launch instead of the help
+	pending *pendingWizard
+	// true while Execute runs the root command, the wizard is launched
+	// after cobra returned
+	deferred bool
+}
+
+type pendingWizard struct {
+	cmd    *cobra.Command
+	wizard func(s *Session) (*InteractiveResult, error)
+	// returns the error of the invocation for a canceled recovery
+	invocationErr func() error
+}
+
+// WithoutInteractiveFlag doesn't add the --interactive flag
+func WithoutInteractiveFlag() InstallOption {
+	return func(c *installConfig) {
+		c.flag = false
+	}
+}
+
+// WithoutInteractiveCommand doesn't add the interactive sub command
+func WithoutInteractiveCommand() InstallOption {
+	return func(c *installConfig) {
+		c.command = false
+	}
+}
+
+// WithRecovery launches the wizard when a normal invocation misses required
+// flags, flags of a flag group or has invalid args. The wizard only asks for the missing input,
+// the values given on the command line are kept. If the wizard is canceled
+// or stdin isn't a terminal, the invocation fails with the error of cobra.
+func WithRecovery() InstallOption {
+	return func(c *installConfig) {
+		c.recovery = true
+	}
+}
+
+// WithUnknownCommandFallback launches the wizard when an unknown sub command
+// is given, e.g. `tool depoly`. The sub command selection starts at the level
+// of the unknown command with the typo as initial query. If stdin isn't a
+// terminal, the invocation fails with the error of cobra.
+func WithUnknownCommandFallback() InstallOption {
+	return func(c *installConfig) {
+		c.fallback = true
+	}
+}
+
+// WithSessionOptions sets the options for the sessions of the launched wizard
+func WithSessionOptions(opts ...Option) InstallOption {
+	return func(c *installConfig) {
+		c.sessionOpts = append(c.sessionOpts, opts...)
+	}
+}
+
+// Install adds a persistent --interactive/-i flag and an interactive sub
+// command to the root command. `tool -i`, `tool deploy -i` and
+// `tool interactive` launch the wizard, beginning at the given command. The
+// selected command is executed like ExecuteInteractive does. Calls without
+// the flag or the sub command are not changed.
+// Install has to be called after all sub commands are added. A root command
+// without sub commands only gets the flag, so its positional args keep
+// working. The root command should be executed with Execute, that returns
+// the error of the command selected in the wizard.
+func Install(root *cobra.Command, opts ...InstallOption) error {
+	cfg := &installConfig{flag: true, command: true}
+	for _, o := range opts {
+		o(cfg)
+	}
+	// cobra rejects the positional args of a root command with sub commands
+	cfg.command = cfg.command && root.HasSubCommands()
+	if cfg.flag && (root.PersistentFlags().Lookup(INTERACTIVE_FLAG) != nil || root.Flags().Lookup(INTERACTIVE_FLAG) != nil) {
+		return fmt.Errorf("flag --%s is already defined", INTERACTIVE_FLAG)
+	}
+	if c, _, err := root.Find([]string{INTERACTIVE_CMD}); cfg.command && err == nil && c != root {
+		return fmt.Errorf("sub command %s is already defined", INTERACTIVE_CMD)
+	}
+	installs.Store(root, cfg)
+	if cfg.flag {
+		shorthand := "i"
+		if isShorthandUsed(root, shorthand) {
+			shorthand = ""
+		}
+		root.PersistentFlags().BoolP(INTERACTIVE_FLAG, shorthand, false, "start the interactive mode")
+		root.PersistentFlags().SetAnnotation(INTERACTIVE_FLAG, internalAnnotation, []string{"true"})
+	}
+	if cfg.fallback && !root.Runnable() && positionalArgs(root) == nil {
+		// cobra rejects unknown sub commands of the root before any hook
+		// is called, except the root has an args validator. It isn't called
+		// for commands that can't run, instead the help function is called.
+		origArgs.Store(root, cobra.PositionalArgs(nil))
+		root.Args = legacyArgs
+	}
+	if cfg.flag || cfg.recovery || cfg.fallback {
+		wrapArgs(root, cfg)
+		helpFunc := root.HelpFunc()
+		root.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+			if p := cfg.pending; p != nil && p.cmd == cmd {
+				cfg.launch(p)
+				return
+			}
+			if typo := unknownSubCommand(cmd); cfg.fallback && typo != "" {
+				if New(cfg.sessionOpts...).interactiveInput() {
+					cfg.launch(&pendingWizard{cmd: cmd, wizard: func(s *Session) (*InteractiveResult, error) {
+						return s.run(cmd, typo, true)
+					}})
+					return
+				}
+				if err := legacyArgs(cmd, cmd.Flags().Args()); err != nil {
+					// without a terminal the root fails like without the
+					// fallback, the error is reported the same way
+					cfg.launch(&pendingWizard{cmd: cmd, wizard: func(s *Session) (*InteractiveResult, error) {
+						return nil, err
+					}})
+					return
+				}
+			}
+			if !interactiveRequested(cmd) {
+				helpFunc(cmd, args)
+				return
+			}
+			// avoid that the flag launches the wizard again in the execution
+			resetInteractiveFlag(cmd)
+			cfg.launch(&pendingWizard{cmd: cmd, wizard: func(s *Session) (*InteractiveResult, error) {
+				return s.start(cmd, cmd.Flags().Args())
+			}})
+		})
+	}
+	if cfg.command {
+		root.AddCommand(&cobra.Command{
+			Use:         INTERACTIVE_CMD + " [command path]",
+			Short:       "Start the interactive mode",
+			Annotations: map[string]string{internalAnnotation: "true"},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				start := root
+				if len(args) > 0 {
+					c, _, err := root.Find(args)
+					if err != nil {
+						return err
+					}
+					start = c
+				}
+				cfg.launch(&pendingWizard{cmd: start, wizard: func(s *Session) (*InteractiveResult, error) {
+					return s.start(start, []string{})
+				}})
+				return nil
+			},
+		})
+	}
+	return nil
+}
+
+// the configurations of the root commands Install was called for
+var installs sync.Map
+
+// Execute executes the root command like root.Execute() does. If the
+// invocation launches the wizard of Install, the wizard runs after cobra
+// parsed the command line and the error of the selected command is returned,
+// or ErrCancelled if the user canceled the wizard. For a root command without
+// Install it is the same as root.Execute().
+func Execute(root *cobra.Command) error {
+	return ExecuteContext(context.Background(), root)
+}
+
+// ExecuteContext is the same as Execute, the context is passed to the
+// executed command
+func ExecuteContext(ctx context.Context, root *cobra.Command) error {
+	v, ok := installs.Load(root)
+	if !ok {
+		return root.ExecuteContext(ctx)
+	}
+	cfg := v.(*installConfig)
+	cfg.pending = nil
+	cfg.deferred = true
+	err := root.ExecuteContext(ctx)
+	cfg.deferred = false
+	p := cfg.pending
+	cfg.pending = nil
+	if err != nil || p == nil {
+		return err
+	}
+	return cfg.runWizard(ctx, p)
+}
+
+// launches the wizard requested by the invocation. Within Execute it is
+// only kept to run after cobra returned, otherwise it runs at once and its
+// errors can't be returned by root.Execute().
+func (c *installConfig) launch(p *pendingWizard) {
+	if c.deferred {
+		c.pending = p
+		return
+	}
+	c.pending = nil
+	c.runWizard(p.cmd.Context(), p)
+}
+
+// runs the wizard and executes the selection, the errors of the execution
+// are printed by cobra. A canceled recovery returns the error of the
+// invocation.
+func (c *installConfig) runWizard(ctx context.Context, p *pendingWizard) error {
+	res, err := p.wizard(New(c.sessionOpts...))
+	if errors.Is(err, ErrCancelled) && p.invocationErr != nil {
+		err = p.invocationErr()
+	}
+	if err != nil {
+		if !errors.Is(err, ErrCancelled) && !p.cmd.SilenceErrors && !p.cmd.Root().SilenceErrors {
+			p.cmd.PrintErrln(p.cmd.ErrPrefix(), err.Error())
+		}
+		return err
+	}
+	return res.ExecuteContext(ctx)
+}
+
+// returns true if the --interactive flag is set for the command, but help
+// wasn't requested
+func interactiveRequested(cmd *cobra.Command) bool {
+	if help, err := cmd.Flags().GetBool("help"); err == nil && help {
+		return false
+	}
+	interactive, err := cmd.Flags().GetBool(INTERACTIVE_FLAG)
+	return err == nil && interactive
+}
+
+func resetInteractiveFlag(cmd *cobra.Command) {
+	if f := cmd.Flags().Lookup(INTERACTIVE_FLAG); f != nil {
+		f.Value.Set("false")
+		f.Changed = false
+	}
+}
+
+// the original args validators of the commands wrapped by Install
+var origArgs sync.Map
+
+// returns the args validator of the command, for commands wrapped by
+// Install the original one
+func positionalArgs(cmd *cobra.Command) cobra.PositionalArgs {
+	if v, ok := origArgs.Load(cmd); ok {
+		return v.(cobra.PositionalArgs)
+	}
+	return cmd.Args
+}
+
+// cobra calls the help function if the args validation returns
+// pflag.ErrHelp. The args of the runnable commands are wrapped to take this
+// way when the --interactive flag is set or input is missing, for commands
+// that can't run cobra calls the help function anyway.
+func wrapArgs(cmd *cobra.Command, cfg *installConfig) {
+	for _, c := range cmd.Commands() {
+		wrapArgs(c, cfg)
+	}
+	if !cmd.Runnable() {
+		return
+	}
+	validator := positionalArgs(cmd)
+	origArgs.Store(cmd, validator)
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		if interactiveRequested(cmd) {
+			return pflag.ErrHelp
+		}
+		if cfg.fallback && validator == nil && legacyArgs(cmd, args) != nil && New(cfg.sessionOpts...).interactiveInput() {
+			// an unknown sub command of the root
+			cfg.pending = &pendingWizard{cmd: cmd, wizard: func(s *Session) (*InteractiveResult, error) {
+				return s.run(cmd, args[0], true)
+			}}
+			return pflag.ErrHelp
+		}
+		if cfg.recovery && (len(missingRequiredFlags(cmd)) > 0 || len(unsatisfiedGroupFlags(cmd)) > 0 || !argsValid(cmd, args)) && New(cfg.sessionOpts...).interactiveInput() {
+			// the wizard asks for the missing input after cobra returned
+			cfg.pending = &pendingWizard{
+				cmd: cmd,
+				wizard: func(s *Session) (*InteractiveResult, error) {
+					return s.collectMissingInput(cmd, args)
+				},
+				invocationErr: func() error {
+					return invocationError(cmd, validator, args)
+				},
+			}
+			return pflag.ErrHelp
+		}
+		if validator == nil {
+			return legacyArgs(cmd, args)
+		}
+		return validator(cmd, args)
+	}
+}
+
+// returns the error cobra reports for the invocation, it validates the args
+// before the required flags and the flag groups
+func invocationError(cmd *cobra.Command, validator cobra.PositionalArgs, args []string) error {
+	if validator == nil {
+		validator = legacyArgs
+	}
+	if err := validator(cmd, args); err != nil {
+		return err
+	}
+	if err := cmd.ValidateRequiredFlags(); err != nil {
+		return err
+	}
+	return cmd.ValidateFlagGroups()
+}
+
+// returns the unknown sub command given for a command that can't run, cobra
+// shows the help in this case
+func unknownSubCommand(cmd *cobra.Command) string {
+	if help, err := cmd.Flags().GetBool("help"); err == nil && help {
+		return ""
+	}
+	if !cmd.HasSubCommands() || cmd.Runnable() || len(cmd.Flags().Args()) == 0 {
+		return ""
+	}
+	return cmd.Flags().Args()[0]
+}
+
+// the validation cobra uses for commands without Args
+func legacyArgs(cmd *cobra.Command, args []string) error {
+	if !cmd.HasSubCommands() {
+		return nil
+	}
+	if !cmd.HasParent() && len(args) > 0 {
+		return fmt.Errorf("unknown command %q for %q%s", args[0], cmd.CommandPath(), findSuggestions(cmd, args[0]))
+	}
+	return nil
+}
+
+// builds the suggestions for an unknown command the same way cobra does
+func findSuggestions(cmd *cobra.Command, arg string) string {
+	if cmd.DisableSuggestions {
+		return ""
+	}
+	if cmd.SuggestionsMinimumDistance <= 0 {
+		cmd.SuggestionsMinimumDistance = 2
+	}
+	var sb strings.Builder
+	if suggestions := cmd.SuggestionsFor(arg); len(suggestions) > 0 {
+		sb.WriteString("\n\nDid you mean this?\n")
+		for _, s := range suggestions {
+			fmt.Fprintf(&sb, "\t%v\n", s)
+		}
+	}
+	return sb.String()
+}
+
+// returns true if a flag of the command tree uses the shorthand
+func isShorthandUsed(cmd *cobra.Command, shorthand string) bool {
+	used := false
+	check := func(f *pflag.Flag) {
+		if f.Shorthand == shorthand {
+			used = true
+		}
+	}
+	cmd.Flags().VisitAll(check)
+	cmd.PersistentFlags().VisitAll(check)
+	for _, c := range cmd.Commands() {
+		if isShorthandUsed(c, shorthand) {
+			used = true
+		}
+	}
+	return used
+}
+
+// returns true if the command was added by Install
+func isInternalCmd(cmd *cobra.Command) bool {
+	_, ok := cmd.Annotations[internalAnnotation]
+	return ok
+}
+
+// returns true if the flag was added by Install
+func isInternalFlag(f *pflag.Flag) bool {
+	_, ok := f.Annotations[internalAnnotation]
+	return ok
+}
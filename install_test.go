@@ -0,0 +1,387 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+type installTestCmds struct {
+	root     *cobra.Command
+	executed []string
+	offered  [][]string
+	env      string
+}
+
+// builds `main` with the sub commands `deploy` and `config set`, the
+// selector answers with the given selections in order
+func newInstallTestCmds(t *testing.T, input string, selections ...string) *installTestCmds {
+	ret := &installTestCmds{}
+	run := func(cmd *cobra.Command, args []string) {
+		ret.executed = append(ret.executed, strings.Join(append([]string{cmd.CommandPath()}, args...), " "))
+	}
+	ret.root = &cobra.Command{Use: "main", Run: run}
+	deployCmd := &cobra.Command{Use: "deploy", Args: cobra.NoArgs, Run: run}
+	deployCmd.Flags().StringVar(&ret.env, "env", "", "the env")
+	configCmd := &cobra.Command{Use: "config"}
+	configCmd.AddCommand(&cobra.Command{Use: "set", Run: run})
+	ret.root.AddCommand(deployCmd, configCmd)
+	ret.root.SetOut(&bytes.Buffer{})
+	ret.root.SetErr(&bytes.Buffer{})
+	require.NoError(t, ic0bra.Install(ret.root, ic0bra.WithSessionOptions(
+		ic0bra.WithInput(strings.NewReader(input)),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			ret.offered = append(ret.offered, options)
+			require.NotEmpty(t, selections, "unexpected selection: %s", promptString)
+			selected := selections[0]
+			selections = selections[1:]
+			return selected, nil
+		}),
+	)))
+	return ret
+}
+
+func (c *installTestCmds) execute(args ...string) error {
+	c.root.SetArgs(args)
+	return ic0bra.Execute(c.root)
+}
+
+func TestInstall_FlagOnRoot(t *testing.T) {
+	cmds := newInstallTestCmds(t, "\nprod\n\n", "deploy")
+	require.NoError(t, cmds.execute("-i"))
+	assert.Equal(t, []string{"main deploy"}, cmds.executed)
+	assert.Equal(t, "prod", cmds.env)
+	// the installed flag and command are not offered
+	assert.Equal(t, []string{"▶ run main here", "config", "deploy", "help — Help about any command"}, cmds.offered[0])
+}
+
+func TestInstall_RunStartCommand(t *testing.T) {
+	// the command the flag is given for can be selected
+	cmds := newInstallTestCmds(t, "\n", fmt.Sprintf(ic0bra.RUN_HERE, "main"))
+	require.NoError(t, cmds.execute("-i"))
+	assert.Equal(t, []string{"main"}, cmds.executed)
+}
+
+func TestInstall_FlagOnSubCommand(t *testing.T) {
+	cmds := newInstallTestCmds(t, "\n", "set")
+	require.NoError(t, cmds.execute("config", "--interactive"))
+	assert.Equal(t, []string{"main config set"}, cmds.executed)
+}
+
+func TestInstall_FlagOnLeaf(t *testing.T) {
+	cmds := newInstallTestCmds(t, "\ntest\n\n")
+	require.NoError(t, cmds.execute("deploy", "-i"))
+	assert.Equal(t, []string{"main deploy"}, cmds.executed)
+	assert.Equal(t, "test", cmds.env)
+}
+
+func TestInstall_Command(t *testing.T) {
+	cmds := newInstallTestCmds(t, "\n", "config", "set")
+	require.NoError(t, cmds.execute("interactive"))
+	assert.Equal(t, []string{"main config set"}, cmds.executed)
+}
+
+func TestInstall_NormalUsage(t *testing.T) {
+	cmds := newInstallTestCmds(t, "")
+	require.NoError(t, cmds.execute("deploy", "--env", "dev"))
+	require.NoError(t, cmds.execute())
+	assert.Equal(t, []string{"main deploy", "main"}, cmds.executed)
+	assert.Empty(t, cmds.offered)
+
+	err := cmds.execute("deploy", "unexpected")
+	assert.ErrorContains(t, err, `unknown command "unexpected" for "main deploy"`)
+}
+
+func TestInstall_UnknownCommand(t *testing.T) {
+	cmds := newInstallTestCmds(t, "")
+	err := cmds.execute("deplyo")
+	assert.ErrorContains(t, err, "Did you mean this?\n\tdeploy")
+}
+
+func TestInstall_ExecuteError(t *testing.T) {
+	errRun := errors.New("run failed")
+	root := &cobra.Command{Use: "main"}
+	root.AddCommand(&cobra.Command{Use: "fail", RunE: func(cmd *cobra.Command, args []string) error {
+		return errRun
+	}})
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	require.NoError(t, ic0bra.Install(root,
+		ic0bra.WithoutInteractiveCommand(),
+		ic0bra.WithSessionOptions(
+			ic0bra.WithInput(strings.NewReader("\n\n")),
+			ic0bra.WithOutput(&bytes.Buffer{}),
+			ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+				return "fail", nil
+			}),
+		),
+	))
+	_, _, err := root.Find([]string{ic0bra.INTERACTIVE_CMD})
+	assert.Error(t, err)
+	for _, args := range [][]string{{"-i"}, {"fail", "-i"}} {
+		root.SetArgs(args)
+		assert.ErrorIs(t, ic0bra.Execute(root), errRun)
+	}
+	// root.Execute() can't return the error of the command selected in the wizard
+	root.SetArgs([]string{"-i"})
+	assert.NoError(t, root.Execute())
+}
+
+func TestInstall_ExecuteCancel(t *testing.T) {
+	cmds := newInstallTestCmds(t, "\nprod\nno\n", "deploy")
+	assert.ErrorIs(t, cmds.execute("-i"), ic0bra.ErrCancelled)
+	assert.Empty(t, cmds.executed)
+}
+
+func TestInstall_Conflicts(t *testing.T) {
+	root := &cobra.Command{Use: "main"}
+	root.Flags().Bool(ic0bra.INTERACTIVE_FLAG, false, "")
+	assert.Error(t, ic0bra.Install(root))
+
+	// the root isn't changed if the installation fails
+	root = &cobra.Command{Use: "main"}
+	interactiveCmd := &cobra.Command{Use: ic0bra.INTERACTIVE_CMD, Run: func(cmd *cobra.Command, args []string) {}}
+	root.AddCommand(interactiveCmd)
+	assert.Error(t, ic0bra.Install(root))
+	assert.Nil(t, root.PersistentFlags().Lookup(ic0bra.INTERACTIVE_FLAG))
+	assert.Nil(t, interactiveCmd.Args)
+
+	root = &cobra.Command{Use: "main"}
+	sub := &cobra.Command{Use: "sub", Run: func(cmd *cobra.Command, args []string) {}}
+	sub.Flags().BoolP("insecure", "i", false, "")
+	root.AddCommand(sub)
+	require.NoError(t, ic0bra.Install(root))
+	assert.Empty(t, root.PersistentFlags().Lookup(ic0bra.INTERACTIVE_FLAG).Shorthand)
+}
+
+func newRecoveryTestRoot(t *testing.T, input string, executed *[]string) *cobra.Command {
+	root := &cobra.Command{Use: "main", PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		*executed = append(*executed, "pre")
+	}}
+	deployCmd := &cobra.Command{
+		Use:  "deploy <target>",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			env, _ := cmd.Flags().GetString("env")
+			region, _ := cmd.Flags().GetString("region")
+			*executed = append(*executed, strings.Join(append([]string{env, region}, args...), " "))
+		},
+	}
+	deployCmd.Flags().String("env", "", "the env")
+	deployCmd.Flags().String("region", "", "the region")
+	deployCmd.Flags().String("comment", "", "a comment")
+	deployCmd.MarkFlagRequired("env")
+	deployCmd.MarkFlagRequired("region")
+	root.AddCommand(deployCmd)
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	require.NoError(t, ic0bra.Install(root, ic0bra.WithRecovery(), ic0bra.WithSessionOptions(
+		ic0bra.WithInput(strings.NewReader(input)),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "", ic0bra.ErrCancelled
+		}),
+	)))
+	return root
+}
+
+func TestInstall_RecoveryMissingFlag(t *testing.T) {
+	executed := make([]string, 0)
+	// only the region is asked for
+	root := newRecoveryTestRoot(t, "\neu\n\n", &executed)
+	root.SetArgs([]string{"deploy", "--env", "prod", "web"})
+	require.NoError(t, ic0bra.Execute(root))
+	// the hooks run once, after the wizard
+	assert.Equal(t, []string{"pre", "prod eu web"}, executed)
+}
+
+func TestInstall_RecoveryMissingArg(t *testing.T) {
+	executed := make([]string, 0)
+	root := newRecoveryTestRoot(t, "db\n\n", &executed)
+	root.SetArgs([]string{"deploy", "--env", "prod", "--region", "us"})
+	require.NoError(t, ic0bra.Execute(root))
+	assert.Equal(t, []string{"pre", "prod us db"}, executed)
+}
+
+func TestInstall_RecoveryCancel(t *testing.T) {
+	executed := make([]string, 0)
+	root := newRecoveryTestRoot(t, "\neu\nno\n", &executed)
+	root.SetArgs([]string{"deploy", "--env", "prod", "web"})
+	// the invocation fails like without recovery
+	assert.ErrorContains(t, ic0bra.Execute(root), `required flag(s) "region" not set`)
+	assert.Empty(t, executed)
+}
+
+func TestInstall_RecoveryEndOfInput(t *testing.T) {
+	executed := make([]string, 0)
+	// the input ends at the prompt of the required --env
+	root := newRecoveryTestRoot(t, "\n", &executed)
+	root.SetArgs([]string{"deploy", "web"})
+	assert.ErrorContains(t, ic0bra.Execute(root), `required flag(s) "env", "region" not set`)
+	assert.Empty(t, executed)
+}
+
+func TestInstall_RecoveryWithoutTerminal(t *testing.T) {
+	origStdinIsTerminal := *ic0bra.StdinIsTerminal
+	defer func() { *ic0bra.StdinIsTerminal = origStdinIsTerminal }()
+	*ic0bra.StdinIsTerminal = func() bool { return false }
+
+	root := &cobra.Command{Use: "main"}
+	deployCmd := &cobra.Command{Use: "deploy", Run: func(cmd *cobra.Command, args []string) {}}
+	deployCmd.Flags().String("env", "", "the env")
+	deployCmd.MarkFlagRequired("env")
+	root.AddCommand(deployCmd)
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	// the wizard would read from stdin
+	require.NoError(t, ic0bra.Install(root, ic0bra.WithRecovery(), ic0bra.WithSessionOptions(ic0bra.WithOutput(&bytes.Buffer{}))))
+	root.SetArgs([]string{"deploy"})
+	assert.ErrorContains(t, ic0bra.Execute(root), `required flag(s) "env" not set`)
+}
+
+func TestInstall_WithoutRecovery(t *testing.T) {
+	root := &cobra.Command{Use: "main"}
+	deployCmd := &cobra.Command{Use: "deploy", Run: func(cmd *cobra.Command, args []string) {}}
+	deployCmd.Flags().String("env", "", "the env")
+	deployCmd.MarkFlagRequired("env")
+	root.AddCommand(deployCmd)
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	require.NoError(t, ic0bra.Install(root))
+	root.SetArgs([]string{"deploy"})
+	assert.ErrorContains(t, ic0bra.Execute(root), `required flag(s) "env" not set`)
+}
+
+func TestInstall_UnknownCommandFallback(t *testing.T) {
+	tests := []struct {
+		name          string
+		runnableRoot  bool
+		args          []string
+		expectedQuery string
+		selection     string
+		expected      string
+	}{
+		{name: "root", args: []string{"depoly"}, expectedQuery: "depoly", selection: "deploy", expected: "main deploy"},
+		{name: "runnable root", runnableRoot: true, args: []string{"depoly"}, expectedQuery: "depoly", selection: "deploy", expected: "main deploy"},
+		{name: "sub command", args: []string{"config", "sett"}, expectedQuery: "sett", selection: "set", expected: "main config set"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			executed := make([]string, 0)
+			run := func(cmd *cobra.Command, args []string) {
+				executed = append(executed, cmd.CommandPath())
+			}
+			root := &cobra.Command{Use: "main"}
+			if test.runnableRoot {
+				root.Run = run
+			}
+			configCmd := &cobra.Command{Use: "config"}
+			configCmd.AddCommand(&cobra.Command{Use: "set", Run: run})
+			root.AddCommand(&cobra.Command{Use: "deploy", Run: run}, configCmd)
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
+			var query string
+			require.NoError(t, ic0bra.Install(root, ic0bra.WithUnknownCommandFallback(), ic0bra.WithSessionOptions(
+				ic0bra.WithInput(strings.NewReader("\n")),
+				ic0bra.WithOutput(&bytes.Buffer{}),
+				ic0bra.WithExtendedSelector(func(promptString string, options []string, cfg ic0bra.SelectConfig) (string, error) {
+					query = cfg.Query
+					return test.selection, nil
+				}),
+			)))
+			root.SetArgs(test.args)
+			require.NoError(t, root.Execute())
+			assert.Equal(t, test.expectedQuery, query)
+			assert.Equal(t, []string{test.expected}, executed)
+
+			// known commands and the help are not changed
+			executed = executed[:0]
+			root.SetArgs([]string{"deploy"})
+			require.NoError(t, root.Execute())
+			assert.Equal(t, []string{"main deploy"}, executed)
+			var out bytes.Buffer
+			root.SetOut(&out)
+			root.SetArgs([]string{"--help"})
+			require.NoError(t, root.Execute())
+			assert.Contains(t, out.String(), "Available Commands")
+		})
+	}
+}
+
+func TestInstall_UnknownCommandFallbackWithoutTerminal(t *testing.T) {
+	origStdinIsTerminal := *ic0bra.StdinIsTerminal
+	defer func() { *ic0bra.StdinIsTerminal = origStdinIsTerminal }()
+	*ic0bra.StdinIsTerminal = func() bool { return false }
+
+	tests := []struct {
+		name         string
+		runnableRoot bool
+	}{
+		{name: "root"},
+		{name: "runnable root", runnableRoot: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := &cobra.Command{Use: "main"}
+			if test.runnableRoot {
+				root.Run = func(cmd *cobra.Command, args []string) {}
+			}
+			root.AddCommand(&cobra.Command{Use: "deploy", Run: func(cmd *cobra.Command, args []string) {}})
+			var errOut bytes.Buffer
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&errOut)
+			// the wizard would read from stdin
+			require.NoError(t, ic0bra.Install(root, ic0bra.WithUnknownCommandFallback(), ic0bra.WithSessionOptions(ic0bra.WithOutput(&bytes.Buffer{}))))
+			root.SetArgs([]string{"depoly"})
+			err := ic0bra.Execute(root)
+			assert.ErrorContains(t, err, `unknown command "depoly" for "main"`)
+			assert.ErrorContains(t, err, "Did you mean this?\n\tdeploy")
+			assert.Contains(t, errOut.String(), `unknown command "depoly"`)
+		})
+	}
+}
+
+func TestInstall_FlatRoot(t *testing.T) {
+	var executed []string
+	root := &cobra.Command{Use: "flat <file>", Args: cobra.ExactArgs(1), Run: func(cmd *cobra.Command, args []string) {
+		executed = append(executed, args...)
+	}}
+	name := root.Flags().String("name", "", "a name")
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	require.NoError(t, ic0bra.Install(root, ic0bra.WithSessionOptions(
+		ic0bra.WithInput(strings.NewReader("\nfoo\nin.txt\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "", ic0bra.ErrCancelled
+		}),
+	)))
+	root.SetArgs([]string{"-i"})
+	require.NoError(t, root.Execute())
+	assert.Equal(t, []string{"in.txt"}, executed)
+	assert.Equal(t, "foo", *name)
+}
+
+func TestInstall_FlatRootNormalUsage(t *testing.T) {
+	var executed []string
+	root := &cobra.Command{Use: "flat [file]", Run: func(cmd *cobra.Command, args []string) {
+		executed = append(executed, args...)
+	}}
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	require.NoError(t, ic0bra.Install(root))
+	root.SetArgs([]string{"in.txt"})
+	require.NoError(t, ic0bra.Execute(root))
+	assert.Equal(t, []string{"in.txt"}, executed)
+	// the sub command isn't added, it would hide the args
+	assert.False(t, root.HasSubCommands())
+}
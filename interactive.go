@@ -2,11 +2,13 @@ package ic0bra
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strings"
 
-	"github.com/fatih/color"
 	"github.com/ktr0731/go-fuzzyfinder"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -19,6 +21,9 @@ var readerFactory = func() *bufio.Reader {
 
 const SELECT_SUB_CMD_PROMPT = "Select the sub command to use: "
 
+// entry in the sub command selection to return to the parent command
+const PARENT_CMD = ".."
+
 // provides the user selection for the command - separated for better testability
 var selectionFactory = func(promptString string, options []string) (string, error) {
 	idx, err := fuzzyfinder.Find(
@@ -32,11 +37,62 @@ var selectionFactory = func(promptString string, options []string) (string, erro
 	return options[idx], nil
 }
 
+// provides the user selection with optional settings - separated for better testability
+var extendedSelectionFactory = func(promptString string, options []string, cfg SelectConfig) (string, error) {
+	if cfg.Preview == nil && cfg.Query == "" {
+		return selectionFactory(promptString, options)
+	}
+	idx, err := fuzzyfinder.Find(
+		options,
+		func(i int) string { return options[i] },
+		findOptions(promptString, cfg)...,
+	)
+	if err != nil {
+		return "", err
+	}
+	return options[idx], nil
+}
+
+// provides the user selection of multiple entries - separated for better testability
+var multiSelectionFactory = func(promptString string, options []string, cfg SelectConfig) ([]string, error) {
+	idxs, err := fuzzyfinder.FindMulti(
+		options,
+		func(i int) string { return options[i] },
+		findOptions(promptString, cfg)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]string, 0, len(idxs))
+	for _, i := range idxs {
+		ret = append(ret, options[i])
+	}
+	return ret, nil
+}
+
+// converts the settings of a selection into fuzzy finder options
+func findOptions(promptString string, cfg SelectConfig) []fuzzyfinder.Option {
+	ret := []fuzzyfinder.Option{fuzzyfinder.WithPromptString(promptString)}
+	if cfg.Preview != nil {
+		ret = append(ret, fuzzyfinder.WithPreviewWindow(func(i, width, height int) string {
+			if i < 0 {
+				return ""
+			}
+			return cfg.Preview(i)
+		}))
+	}
+	if cfg.Query != "" {
+		ret = append(ret, fuzzyfinder.WithQuery(cfg.Query))
+	}
+	return ret
+}
+
 // This function enables a fuzzy style interactive execution, without
 // passing all required sub commands and flags at start time.
 // cmd - cobra root command
-func RunInteractive(cmd *cobra.Command) (*cobra.Command, error) {
-	return runInteractiveImpl(cmd, nil)
+// opts - optional session configuration
+func RunInteractive(cmd *cobra.Command, opts ...Option) (*InteractiveResult, error) {
+	return New(opts...).Run(cmd)
 }
 
 // This function enables a fuzzy style interactive execution, without
@@ -45,12 +101,22 @@ func RunInteractive(cmd *cobra.Command) (*cobra.Command, error) {
 // history
 // cmd - cobra root command
 // appName - used as entry directory in the user config folder to store the history values
-func RunInteractiveWithHistory(cmd *cobra.Command, appName string) (*cobra.Command, error) {
+// opts - optional session configuration
+func RunInteractiveWithHistory(cmd *cobra.Command, appName string, opts ...Option) (*InteractiveResult, error) {
 	histProvider, err := NewFileHistoryProvider(appName)
 	if err != nil {
 		return nil, err
 	}
-	return runInteractiveImpl(cmd, histProvider)
+	return New(append([]Option{WithHistoryProvider(histProvider)}, opts...)...).Run(cmd)
+}
+
+// RunInteractiveArgs starts the wizard at the command given by the args, see
+// Session.RunArgs
+// root - cobra root command
+// args - command line args, e.g. os.Args[1:]
+// opts - optional session configuration
+func RunInteractiveArgs(root *cobra.Command, args []string, opts ...Option) (*InteractiveResult, error) {
+	return New(opts...).RunArgs(root, args)
 }
 
 type HistoryProvider interface {
@@ -59,43 +125,153 @@ type HistoryProvider interface {
 	SaveHist(flagName, value string) error
 }
 
-func runInteractiveImpl(cmd *cobra.Command, histProvider HistoryProvider) (*cobra.Command, error) {
-	subCommands := cmd.Commands()
-	if len(subCommands) == 0 {
-		return nil, fmt.Errorf("command has no sub commads")
+// Run starts the interactive selection of the sub command to call, beginning
+// at the given command, and collects the input for the flags of the selected
+// command chain. The given command itself isn't offered to run, it usually
+// starts the wizard in its Run function. If the user cancels the run, the
+// returned error is ErrCancelled and the result is marked as canceled.
+func (s *Session) Run(cmd *cobra.Command) (*InteractiveResult, error) {
+	return s.run(cmd, "", false)
+}
+
+// RunArgs starts the wizard at the command given by the args, e.g.
+// `cluster node --region eu` starts at `cluster node`. The flags in the args
+// are set and not asked for again, the positional args are kept for a
+// command without sub commands.
+func (s *Session) RunArgs(root *cobra.Command, args []string) (*InteractiveResult, error) {
+	cmd, flags, err := root.Find(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.ParseFlags(flags); err != nil {
+		return nil, err
+	}
+	return s.start(cmd, cmd.Flags().Args())
+}
+
+// starts the wizard at the command, positional are the args given for it.
+// For a command with sub commands the first one is an unknown sub command,
+// that is used as initial query of the selection.
+func (s *Session) start(cmd *cobra.Command, positional []string) (*InteractiveResult, error) {
+	if len(commandEntries(cmd)) == 0 && cmd.Runnable() {
+		return s.collectInput(cmd, positional, s.newReader())
+	}
+	query := ""
+	if len(positional) > 0 {
+		query = positional[0]
+	}
+	return s.run(cmd, query, true)
+}
+
+// runs the wizard, query is the initial search input of the first sub
+// command selection. offerStart offers to run the command the wizard starts
+// from, that isn't wanted if the command starts the wizard in its Run
+// function.
+func (s *Session) run(cmd *cobra.Command, query string, offerStart bool) (*InteractiveResult, error) {
+	reader := s.newReader()
+	if len(commandEntries(cmd)) == 0 {
+		if !cmd.Runnable() {
+			return nil, ErrNoSubCommands
+		}
+		// e.g. a root command of a tool without sub commands
+		return s.collectInput(cmd, []string{}, reader)
 	}
 	currentCmd := cmd
 	for {
-		options := getOptionsFromCommands(subCommands...)
-		selected, err := selectionFactory(SELECT_SUB_CMD_PROMPT, options)
-		if err != nil {
-			return nil, fmt.Errorf("error in interactive run: %v", err)
+		entries := commandEntries(currentCmd)
+		if currentCmd.Runnable() && (offerStart || currentCmd != cmd) {
+			entries = append([]cmdEntry{{label: fmt.Sprintf(RUN_HERE, currentCmd.Name()), cmd: currentCmd, runHere: true}}, entries...)
 		}
-		if strings.HasPrefix(selected, "help ") {
-			helpCmd, _, err := currentCmd.Find([]string{"help"})
-			return helpCmd, err
+		if currentCmd != cmd {
+			entries = append([]cmdEntry{{label: PARENT_CMD}}, entries...)
+		}
+		selected, err := s.extendedSelectFn()(SELECT_SUB_CMD_PROMPT, entryLabels(entries), SelectConfig{
+			Preview: func(i int) string {
+				return entryPreview(currentCmd, entries[i])
+			},
+			Query: query,
+		})
+		query = ""
+		if aborted(err) {
+			return &InteractiveResult{Cancelled: true}, ErrCancelled
 		}
-		nextCmd, _, err := currentCmd.Find([]string{selected})
 		if err != nil {
-			return nil, fmt.Errorf("error finding seleted sub command: %v", err)
+			return nil, fmt.Errorf("error in interactive run: %w", err)
 		}
-		subCommands = nextCmd.Commands()
-		if len(subCommands) == 0 {
-			// reached end of the chain ..
-			cmdChain, txt := getCommandChain(nextCmd)
-			configuredFlags := setFlagsForCommands(txt, histProvider, cmdChain...)
-			printInfo("\nresulting program call:\n\n")
-			color.Yellow("  %s %s\n", txt, configuredFlags)
-			if !shouldContinue() {
-				fmt.Println("Cancel.")
-				return nil, nil
+		entry, ok := findEntry(entries, selected)
+		if ok && entry.cmd == nil {
+			currentCmd = currentCmd.Parent()
+			continue
+		}
+		if entry.runHere {
+			return s.collectInput(currentCmd, []string{}, reader)
+		}
+		nextCmd := entry.cmd
+		if !ok {
+			if nextCmd, _, err = currentCmd.Find([]string{selected}); err != nil {
+				return nil, fmt.Errorf("error finding seleted sub command: %v", err)
 			}
-			return nextCmd, nil
+		}
+		if nextCmd.Name() == "help" && !nextCmd.HasSubCommands() {
+			return newInteractiveResult(nextCmd, nil, nil, false), nil
+		}
+		if len(commandEntries(nextCmd)) == 0 {
+			// reached end of the chain ..
+			return s.collectInput(nextCmd, []string{}, reader)
 		}
 		currentCmd = nextCmd
 	}
 }
 
+// collects the flags and args for the selected command and asks for the
+// confirmation of the resulting program call. The collection of the args
+// continues after the given ones.
+func (s *Session) collectInput(cmd *cobra.Command, args []string, reader *bufio.Reader) (*InteractiveResult, error) {
+	_, txt := getCommandChain(cmd)
+	fc := s.newFlagCollector(reader, getFlagsToAsk(cmd))
+	if err := fc.collectAll(txt); err != nil {
+		return cancelledResult(err)
+	}
+	return s.completeInput(cmd, fc, args, reader)
+}
+
+// returns the result for a collection that was stopped by the error, e.g.
+// at the end of the input
+func cancelledResult(err error) (*InteractiveResult, error) {
+	if errors.Is(err, ErrCancelled) {
+		return &InteractiveResult{Cancelled: true}, err
+	}
+	return nil, err
+}
+
+// collects the args after the given ones, asks for the confirmation of the
+// resulting program call and applies the collected flags
+func (s *Session) completeInput(cmd *cobra.Command, fc *flagCollector, args []string, reader *bufio.Reader) (*InteractiveResult, error) {
+	args, err := s.collectArgs(cmd, args, reader)
+	if err != nil {
+		return cancelledResult(err)
+	}
+	var confirmed bool
+	if s.review {
+		args, confirmed = s.reviewInput(cmd, fc, args, reader)
+	}
+	result := newInteractiveResult(cmd, fc.result(), args, s.shorthands)
+	if !s.review {
+		s.printInfo("\nresulting program call:\n\n")
+		s.theme.CommandLine.Fprintf(s.out, "  %s\n", result.CommandLine)
+		confirmed = s.shouldContinue(result.CommandLine, reader)
+	}
+	if !confirmed {
+		fmt.Fprintln(s.out, "Cancel.")
+		result.Cancelled = true
+		return result, ErrCancelled
+	}
+	if err := fc.apply(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
 // Provides the chain of all included sub commands for a given command
 func getCommandChain(cmd *cobra.Command) ([]*cobra.Command, string) {
 	txt := ""
@@ -103,9 +279,9 @@ func getCommandChain(cmd *cobra.Command) ([]*cobra.Command, string) {
 	for c := cmd; c != nil; c = c.Parent() {
 		chain = append(chain, c)
 		if txt != "" {
-			txt = c.Use + " " + txt
+			txt = c.Name() + " " + txt
 		} else {
-			txt = c.Use
+			txt = c.Name()
 		}
 	}
 	return chain, txt
@@ -121,21 +297,22 @@ func isFlagRequired(f *pflag.Flag) bool {
 	return false
 }
 
+// returns true if the flag takes multiple values, e.g. a stringSlice
 func isRepeatableFlag(f *pflag.Flag) bool {
-	switch f.Value.Type() {
-	case "stringSlice", "intSlice", "float32Slice", "boolSlice", "stringArray":
-		return true
-	default:
-		return false
-	}
+	_, ok := f.Value.(pflag.SliceValue)
+	return ok
 }
 
-func shouldContinue() bool {
-	reader := readerFactory()
+// asks the user if the resulting program call should be executed, a
+// configured ConfirmFunc replaces the question
+func (s *Session) shouldContinue(commandLine string, reader *bufio.Reader) bool {
+	if s.confirm != nil {
+		return s.confirm(commandLine)
+	}
 	count := 0
 	maxCount := 10
 	for {
-		printInfo("\nShould the program execution be continued (default is yes)? [yes|no]: ")
+		s.printInfo("\nShould the program execution be continued (default is yes)? [yes|no]: ")
 		input, _ := reader.ReadString('\n') // read entire line
 		input = strings.TrimSpace(input)    // remove newline and spaces
 		if len(input) == 0 {
@@ -148,117 +325,308 @@ func shouldContinue() bool {
 		case "no", "n":
 			return false
 		default:
-			fmt.Println("wrong input ... only [yes|no|empty] are allowed!")
+			s.printWarning("wrong input ... only [yes|no|empty] are allowed!\n")
 		}
 		count++
 		if count == maxCount {
-			fmt.Println("I am tired of it ... programm execution is canceled!")
+			fmt.Fprintln(s.out, "I am tired of it ... programm execution is canceled!")
 			return false
 		}
 	}
 }
 
-// iterates over the selected commands and collects input for their configured flags
-func setFlagsForCommands(cmdChain string, histProvider HistoryProvider, cmds ...*cobra.Command) string {
-	showedChain := false
-	configuredFlags := ""
-	reader := readerFactory()
-	collectRepeatedFlagInputFunc := collectRepeatedFlagInput
-	collectFlagInputFunc := collectFlagInput
-	if histProvider != nil {
-		collectFlagInputFunc = func(cmd *cobra.Command, f *pflag.Flag, flagRequired bool, defValue string, reader *bufio.Reader, maxFlags int, currentFlag *int) string {
-			return collectFlagInputWithHist(cmd, f, flagRequired, defValue, reader, histProvider, maxFlags, currentFlag)
+// state of the flag collection for the selected command chain
+type flagCollector struct {
+	s      *Session
+	reader *bufio.Reader
+	flags  []flagToAsk
+	// flags that were already set before the wizard started, e.g. on the
+	// command line. They aren't asked for, but are part of the result.
+	preset    []collectedFlag
+	collected [][]string
+	// the flag groups of cobra and the choices made for the mutually
+	// exclusive ones, by the key of the group
+	groups  []flagGroup
+	choices map[string]flagChoice
+	// flag chosen to satisfy a one-required group
+	forced string
+}
+
+func (s *Session) newFlagCollector(reader *bufio.Reader, flags []flagToAsk) *flagCollector {
+	ret := &flagCollector{
+		s:       s,
+		reader:  reader,
+		flags:   make([]flagToAsk, 0, len(flags)),
+		preset:  make([]collectedFlag, 0),
+		choices: make(map[string]flagChoice),
+	}
+	// hidden and deprecated flags that are set are kept for the result
+	flags = slices.DeleteFunc(slices.Clone(flags), func(ftc flagToAsk) bool {
+		return !ftc.flag.Changed && s.skipFlag(ftc.flag)
+	})
+	all := make([]*pflag.Flag, 0, len(flags))
+	for _, ftc := range flags {
+		all = append(all, ftc.flag)
+	}
+	ret.groups = flagGroups(all)
+	for _, ftc := range groupFlagOrder(flags, ret.groups) {
+		if ftc.flag.Changed {
+			ret.preset = append(ret.preset, collectedFlag{flag: ftc.flag, values: flagValues(ftc.flag)})
+		} else {
+			ret.flags = append(ret.flags, ftc)
+		}
+	}
+	ret.collected = make([][]string, len(ret.flags))
+	return ret
+}
+
+// iterates over the selected commands and collects input for their
+// configured flags. It returns ErrCancelled if the input ends before all
+// flags were asked for.
+func (fc *flagCollector) collectAll(cmdChain string) error {
+	if len(fc.flags) == 0 {
+		return nil
+	}
+	fc.s.printInfo(fmt.Sprintf("\n`%s` will be called.\n\nIn the following steps the possible flags will be collected, enter '%s' to return to the previous flag. Continue with ⏎\n", cmdChain, BACK))
+	if _, err := readInput(fc.reader); err != nil {
+		return ErrCancelled
+	}
+	if !fc.s.pickFlags {
+		indexes := make([]int, len(fc.flags))
+		for i := range fc.flags {
+			indexes[i] = i
 		}
-		collectRepeatedFlagInputFunc = func(cmd *cobra.Command, f *pflag.Flag, defValue string, reader *bufio.Reader, maxFlags int, currentFlag *int) string {
-			return collectRepeatedFlagInputWithHist(cmd, f, defValue, reader, histProvider, maxFlags, currentFlag)
+		if err := fc.collectIndexes(indexes); err != nil {
+			return err
 		}
+		return fc.completeGroups()
 	}
-	flagCount := getFlagCount(cmds...)
-	currentFlag := 1
-	for _, cmd := range cmds {
-		// Iterate over all flags of the command
-		cmd.Flags().VisitAll(func(f *pflag.Flag) {
-			// Ask interactively for input
-			if f.Name == "help" {
-				return
-			}
+	required := make([]int, 0)
+	optional := make([]int, 0)
+	for i, ftc := range fc.flags {
+		if isFlagRequired(ftc.flag) {
+			required = append(required, i)
+		} else {
+			optional = append(optional, i)
+		}
+	}
+	if err := fc.collectIndexes(required); err != nil {
+		return err
+	}
+	if err := fc.collectIndexes(fc.pickOptionalFlags(optional)); err != nil {
+		return err
+	}
+	return fc.completeGroups()
+}
 
-			if !showedChain {
-				printInfo(fmt.Sprintf("\n`%s` will be called.\n\nIn the following steps the possible flags will be collected. Continue with ⏎\n", cmdChain))
-				//fmt.Printf("\n`%s` will be called.\n\nIn the following steps the possible flags will be collected. Continue with ⏎\n", cmdChain)
-				reader.ReadString('\n') // read entire line
-				showedChain = true
+// asks for the flags with the given indexes in their order
+func (fc *flagCollector) collectIndexes(indexes []int) error {
+	// the positions that were asked for, to step back
+	history := make([]int, 0, len(indexes))
+	for pos := 0; pos < len(indexes); {
+		i := indexes[pos]
+		if fc.skipped(i) {
+			// another flag of a mutually exclusive group is set
+			pos++
+			continue
+		}
+		if global := fc.flags[i].global; len(history) == 0 || global != fc.flags[indexes[history[len(history)-1]]].global {
+			fc.s.printInfo(fmt.Sprintf("\n%s:\n", flagsHeader(global)))
+		}
+		err := fc.chooseExclusive(i, indexes[pos:])
+		if err == nil && !fc.skipped(i) {
+			err = fc.ask(i, pos+1, len(indexes))
+		}
+		if errors.Is(err, ErrBack) {
+			if len(history) == 0 {
+				fc.s.printWarning("⚠️  This is already the first flag\n")
+				continue
 			}
+			pos = history[len(history)-1]
+			history = history[:len(history)-1]
+			fc.clear(indexes[pos])
+			fc.dropChoices(indexes[pos])
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		history = append(history, pos)
+		pos++
+	}
+	return nil
+}
 
-			defValue := ""
-			flagRequired := isFlagRequired(f)
-			if flagRequired {
-				defValue = "(required)" //"press ⏎ to skip"
-			} else {
-				if f.DefValue != "" {
-					defValue = fmt.Sprintf("(default %v)", f.DefValue)
-				}
+// asks for the flag with the given index, previous input for the flag is
+// dropped. pos and total are used for the [pos/total] display.
+func (fc *flagCollector) ask(i, pos, total int) error {
+	fc.clear(i)
+	cmd, f := fc.flags[i].cmd, fc.flags[i].flag
+	defValue := ""
+	flagRequired := fc.isRequired(i)
+	if flagRequired {
+		defValue = "(required)" //"press ⏎ to skip"
+	} else {
+		if f.DefValue != "" {
+			defValue = fmt.Sprintf("(default %v)", f.DefValue)
+		}
+	}
+	fc.s.printDeprecation(f)
+	values, err := fc.s.flagPrompter(f).Prompt(&FlagPrompt{
+		Cmd:      cmd,
+		Flag:     f,
+		Required: flagRequired,
+		Hint:     defValue,
+		Pos:      pos,
+		Total:    total,
+		s:        fc.s,
+		reader:   fc.reader,
+	})
+	if err != nil {
+		fc.clear(i)
+		if errors.Is(err, io.EOF) {
+			// e.g. a prompter that returns the error of ReadLine
+			return ErrCancelled
+		}
+		return err
+	}
+	fc.collected[i] = values
+	return nil
+}
+
+// drops the staged input of the flag with the given index
+func (fc *flagCollector) clear(i int) {
+	fc.collected[i] = nil
+}
+
+// sets the staged input on the cobra flags. If a value can't be set, all
+// flags are restored to the state before. Map flags can't be restored, so
+// they are set last.
+func (fc *flagCollector) apply() error {
+	snapshots := make([]flagSnapshot, len(fc.flags))
+	for i, ftc := range fc.flags {
+		snapshots[i] = takeFlagSnapshot(ftc.flag)
+	}
+	order := make([]int, 0, len(fc.collected))
+	for _, maps := range []bool{false, true} {
+		for i := range fc.collected {
+			if isMapFlag(fc.flags[i].flag) == maps {
+				order = append(order, i)
 			}
-			if isRepeatableFlag(f) {
-				configuredFlags += collectRepeatedFlagInputFunc(cmd, f, defValue, reader, flagCount, &currentFlag)
-			} else {
-				configuredFlags += collectFlagInputFunc(cmd, f, flagRequired, defValue, reader, flagCount, &currentFlag)
+		}
+	}
+	for _, i := range order {
+		values := fc.collected[i]
+		if len(values) == 0 {
+			continue
+		}
+		ftc := fc.flags[i]
+		if err := applyFlagValues(ftc.cmd.Flags(), ftc.flag, values); err != nil {
+			for j, snapshot := range snapshots {
+				snapshot.restore(fc.flags[j].flag)
 			}
-		})
+			return fmt.Errorf("error while setting flag --%s: %v", ftc.flag.Name, err)
+		}
+	}
+	return nil
+}
+
+// returns the preset flags and the flags with input in the order they were
+// asked for
+func (fc *flagCollector) result() []collectedFlag {
+	ret := slices.Clone(fc.preset)
+	for i, values := range fc.collected {
+		if len(values) > 0 {
+			ret = append(ret, collectedFlag{flag: fc.flags[i].flag, values: values})
+		}
+	}
+	return ret
+}
+
+// header printed before the flags of the command and before the inherited
+// global flags
+const COMMAND_FLAGS_HEADER = "command flags"
+const GLOBAL_FLAGS_HEADER = "global flags"
+
+func flagsHeader(global bool) string {
+	if global {
+		return GLOBAL_FLAGS_HEADER
 	}
-	return configuredFlags
+	return COMMAND_FLAGS_HEADER
 }
 
-func getFlagCount(cmds ...*cobra.Command) int {
-	var flagCount int
-	for _, c := range cmds {
-		c.Flags().VisitAll(func(f *pflag.Flag) {
-			// Ask interactively for input
-			if f.Name != "help" {
-				flagCount++
+// a flag to ask for and the command it is asked for
+type flagToAsk struct {
+	cmd  *cobra.Command
+	flag *pflag.Flag
+	// true for persistent flags inherited from a parent command
+	global bool
+}
+
+// returns the effective flags of the command, each flag only once. The
+// flags of the command itself come first, then the persistent flags
+// inherited from the parents.
+func getFlagsToAsk(cmd *cobra.Command) []flagToAsk {
+	ret := make([]flagToAsk, 0)
+	add := func(global bool) func(f *pflag.Flag) {
+		return func(f *pflag.Flag) {
+			if f.Name != "help" && !isInternalFlag(f) {
+				ret = append(ret, flagToAsk{cmd: cmd, flag: f, global: global})
 			}
-		})
+		}
 	}
-	return flagCount
+	cmd.LocalFlags().VisitAll(add(false))
+	cmd.InheritedFlags().VisitAll(add(true))
+	return ret
 }
 
 const HELP = "?"
 const HELP2 = "help"
 const HELP3 = "--help"
 
+// input to return to the previous flag or argument
+const BACK = "<"
+
 // implements the user interaction to get the required input for a flag
-func collectFlagInput(cmd *cobra.Command, f *pflag.Flag, flagRequired bool, defValue string, reader *bufio.Reader, maxFlags int, currentFlag *int) string {
+func (s *Session) collectFlagInput(cmd *cobra.Command, f *pflag.Flag, flagRequired bool, defValue string, reader *bufio.Reader, maxFlags int, currentFlag int) ([]string, error) {
 	var setValue string
+	candidates := flagCandidates(cmd, f)
 	for {
-		fmt.Printf("\n[%d/%d] --%s: %s: ", *currentFlag, maxFlags, f.Name, defValue)
-		input, _ := reader.ReadString('\n') // read entire line
-		input = trimInput(input)            // remove newline and spaces
+		input, fromCandidates := s.selectFlagCandidate(f, candidates, nil, maxFlags, currentFlag)
+		if !fromCandidates {
+			fmt.Fprintf(s.out, "\n[%d/%d] --%s: %s: ", currentFlag, maxFlags, f.Name, defValue)
+			var err error
+			if input, err = readInput(reader); err != nil {
+				return nil, ErrCancelled
+			}
+		}
 
+		if input == BACK {
+			return nil, ErrBack
+		}
 		if input != "" {
 			if input == HELP || input == HELP2 || input == HELP3 {
-				fmt.Printf("  Expected input: %s\n", f.Usage)
+				fmt.Fprintf(s.out, "  Expected input: %s\n", f.Usage)
 			} else {
-				// User provided a value -> set it
-				if err := cmd.Flags().Set(f.Name, input); err != nil {
-					fmt.Printf("⚠️  Could not set flag %s: %v\n", f.Name, err)
+				// User provided a value -> stage it
+				if err := validateFlagValue(f, input); err != nil {
+					s.printWarning(fmt.Sprintf("⚠️  Could not set flag %s: %v\n", f.Name, err))
 				} else {
-					fmt.Printf("\nSet value: --%s %s\n", f.Name, input)
+					fmt.Fprintf(s.out, "\nSet value: --%s %s\n", f.Name, input)
 					setValue = input
 					break
 				}
 			}
 		} else if flagRequired {
-			fmt.Printf("⚠️  Flag %s is required, so input is needed!\n", f.Name)
+			s.printWarning(fmt.Sprintf("⚠️  Flag %s is required, so input is needed!\n", f.Name))
 		} else {
 			break
 		}
 	}
-	*currentFlag++
 	if setValue != "" {
-		return flagTxt(f, setValue)
-	} else {
-		return setValue
+		return []string{setValue}, nil
 	}
+	return nil, nil
 }
 
 func getHistHint(flagName string, histProv HistoryProvider) (bool, string) {
@@ -271,131 +639,210 @@ func getHistHint(flagName string, histProv HistoryProvider) (bool, string) {
 	return false, ""
 }
 
-func printInfo(msg string) {
-	c := color.New(color.FgHiBlue)
-	c.Print(msg)
+func (s *Session) printInfo(msg string) {
+	s.theme.Info.Fprint(s.out, msg)
 }
 
-func collectRepeatedFlagInput(cmd *cobra.Command, f *pflag.Flag, defValue string, reader *bufio.Reader, maxFlags int, currentFlag *int) string {
-	var setValue string
+func (s *Session) printWarning(msg string) {
+	s.theme.Warning.Fprint(s.out, msg)
+}
+
+func (s *Session) collectRepeatedFlagInput(cmd *cobra.Command, f *pflag.Flag, defValue string, reader *bufio.Reader, maxFlags int, currentFlag int) ([]string, error) {
+	var setValues []string
 	bFirst := true
+	candidates := flagCandidates(cmd, f)
 	for {
-		if bFirst {
-			fmt.Printf("\n[%d/%d] --%s %s\nmultiple values possible: ", *currentFlag, maxFlags, f.Name, defValue)
-			bFirst = false
-		} else {
-			fmt.Printf("\nnext value, empty input to finish: ")
+		input, fromCandidates := s.selectFlagCandidate(f, candidates, setValues, maxFlags, currentFlag)
+		if !fromCandidates {
+			// after ESC the values are entered by hand
+			candidates = nil
+			if bFirst {
+				fmt.Fprintf(s.out, "\n[%d/%d] --%s %s\nmultiple values possible: ", currentFlag, maxFlags, f.Name, defValue)
+				bFirst = false
+			} else {
+				fmt.Fprintf(s.out, "\nnext value, empty input to finish: ")
+			}
+			var err error
+			if input, err = readInput(reader); err != nil {
+				return nil, ErrCancelled
+			}
 		}
-		input, _ := reader.ReadString('\n') // read entire line
-		input = trimInput(input)            // remove newline and spaces
 
+		if input == BACK {
+			return nil, ErrBack
+		}
 		if input != "" {
 			if input == HELP || input == HELP2 || input == HELP3 {
-				fmt.Printf("  Expected input: %s\n", f.Usage)
+				fmt.Fprintf(s.out, "  Expected input: %s\n", f.Usage)
 			} else {
-				// User provided a value -> set it
-				if err := cmd.Flags().Set(f.Name, input); err != nil {
-					fmt.Printf("⚠️  Could not set flag %s: %v\n", f.Name, err)
+				// User provided a value -> stage it
+				if err := validateFlagValue(f, input); err != nil {
+					s.printWarning(fmt.Sprintf("⚠️  Could not set flag %s: %v\n", f.Name, err))
 				} else {
-					fmt.Printf("\nSet value: --%s %s\n", f.Name, input)
-					setValue += flagTxt(f, input)
+					fmt.Fprintf(s.out, "\nSet value: --%s %s\n", f.Name, input)
+					setValues = append(setValues, input)
 				}
 			}
 		} else {
 			break
 		}
 	}
-	*currentFlag++
-	return setValue
+	return setValues, nil
 }
 
-func getHistInput(f *pflag.Flag, hasHist bool, reader *bufio.Reader, histProvider HistoryProvider, defValue, histHint string, txtToIgnore []string, maxFlags, currentFlag int) (string, bool) {
+// returns the input from the history or entered by hand, the second return
+// value is true if it was taken from the history
+func (s *Session) getHistInput(f *pflag.Flag, hasHist bool, reader *bufio.Reader, defValue, histHint string, txtToIgnore []string, maxFlags, currentFlag int) (string, bool, error) {
 	if hasHist {
-		if input, err := histProvider.InputFromHist(f.Name, fmt.Sprintf("\n'--%s' %s (%s), to enter new value press ESC", f.Name, defValue, f.Usage), txtToIgnore, maxFlags, currentFlag); err == nil {
-			return trimInput(input), true
+		if input, err := s.histProvider.InputFromHist(f.Name, fmt.Sprintf("\n'--%s' %s (%s), to enter new value press ESC", f.Name, defValue, f.Usage), txtToIgnore, maxFlags, currentFlag); err == nil {
+			return trimInput(input), true, nil
 		}
 	}
-	printInfo(histHint)
-	input, _ := reader.ReadString('\n') // read entire line
-	input = trimInput(input)
-	return input, false
+	s.printInfo(histHint)
+	input, err := readInput(reader)
+	return input, false, err
 }
 
-func collectFlagInputWithHist(cmd *cobra.Command, f *pflag.Flag, flagRequired bool, defValue string, reader *bufio.Reader, histProvider HistoryProvider, maxFlags int, currentFlag *int) string {
+func (s *Session) collectFlagInputWithHist(cmd *cobra.Command, f *pflag.Flag, flagRequired bool, defValue string, reader *bufio.Reader, maxFlags int, currentFlag int) ([]string, error) {
 	var setValue string
+	candidates := flagCandidates(cmd, f)
 	for {
-		hasHist, _ := getHistHint(f.Name, histProvider)
-		histHint := fmt.Sprintf("\n[%d/%d] new value for: --%s %s: ", *currentFlag, maxFlags, f.Name, defValue)
-		input, _ := getHistInput(f, hasHist, reader, histProvider, defValue, histHint, []string{}, maxFlags, *currentFlag)
+		input, fromCandidates := s.selectFlagCandidate(f, candidates, nil, maxFlags, currentFlag)
+		if !fromCandidates {
+			hasHist, _ := getHistHint(f.Name, s.histProvider)
+			histHint := fmt.Sprintf("\n[%d/%d] new value for: --%s %s: ", currentFlag, maxFlags, f.Name, defValue)
+			var err error
+			if input, _, err = s.getHistInput(f, hasHist, reader, defValue, histHint, []string{}, maxFlags, currentFlag); err != nil {
+				return nil, ErrCancelled
+			}
+		}
 
+		if input == BACK {
+			return nil, ErrBack
+		}
 		if input != "" {
 			if input == HELP || input == HELP2 || input == HELP3 {
-				fmt.Printf("  Expected input: %s\n", f.Usage)
+				fmt.Fprintf(s.out, "  Expected input: %s\n", f.Usage)
 			} else {
-				// User provided a value -> set it
-				if err := cmd.Flags().Set(f.Name, input); err != nil {
-					fmt.Printf("⚠️  Could not set flag %s: %v\nContinue with ⏎\n", f.Name, err)
-					reader.ReadString('\n') // read entire line
+				// User provided a value -> stage it
+				if err := validateFlagValue(f, input); err != nil {
+					s.printWarning(fmt.Sprintf("⚠️  Could not set flag %s: %v\nContinue with ⏎\n", f.Name, err))
+					if _, err := readInput(reader); err != nil {
+						return nil, ErrCancelled
+					}
 				} else {
-					fmt.Printf("\nSet value: --%s %s\n", f.Name, input)
+					fmt.Fprintf(s.out, "\nSet value: --%s %s\n", f.Name, input)
 					setValue = input
-					histProvider.SaveHist(f.Name, setValue)
+					s.histProvider.SaveHist(f.Name, setValue)
 					break
 				}
 			}
 		} else if flagRequired {
-			fmt.Printf("⚠️  Flag %s is required, so input is needed! Continue with ⏎\n", f.Name)
-			reader.ReadString('\n') // read entire line
+			s.printWarning(fmt.Sprintf("⚠️  Flag %s is required, so input is needed! Continue with ⏎\n", f.Name))
+			if _, err := readInput(reader); err != nil {
+				return nil, ErrCancelled
+			}
 		} else {
 			break
 		}
 	}
-	*currentFlag++
 	if setValue != "" {
-		return flagTxt(f, setValue)
-	} else {
-		return setValue
+		return []string{setValue}, nil
 	}
+	return nil, nil
 }
 
-func collectRepeatedFlagInputWithHist(cmd *cobra.Command, f *pflag.Flag, defValue string, reader *bufio.Reader, histProvider HistoryProvider, maxFlags int, currentFlag *int) string {
-	var setValue string
-	hasHist, _ := getHistHint(f.Name, histProvider)
-	histHint := fmt.Sprintf("\n[%d/%d] --%s %s\nmultiple values possible, leave empty to skip or finish: ", *currentFlag, maxFlags, f.Name, defValue)
+func (s *Session) collectRepeatedFlagInputWithHist(cmd *cobra.Command, f *pflag.Flag, defValue string, reader *bufio.Reader, maxFlags int, currentFlag int) ([]string, error) {
+	var setValues []string
+	hasHist, _ := getHistHint(f.Name, s.histProvider)
+	histHint := fmt.Sprintf("\n[%d/%d] --%s %s\nmultiple values possible, leave empty to skip or finish: ", currentFlag, maxFlags, f.Name, defValue)
 	txtToIgnore := make([]string, 0)
+	candidates := flagCandidates(cmd, f)
 	for {
-		input, fromHist := getHistInput(f, hasHist, reader, histProvider, defValue, histHint, txtToIgnore, maxFlags, *currentFlag)
-		if !fromHist {
-			hasHist = false
+		input, fromCandidates := s.selectFlagCandidate(f, candidates, txtToIgnore, maxFlags, currentFlag)
+		if !fromCandidates {
+			// after ESC the values are taken from the history or entered by hand
+			candidates = nil
+			var fromHist bool
+			var err error
+			if input, fromHist, err = s.getHistInput(f, hasHist, reader, defValue, histHint, txtToIgnore, maxFlags, currentFlag); err != nil {
+				return nil, ErrCancelled
+			}
+			if !fromHist {
+				hasHist = false
+			}
 		}
 
+		if input == BACK {
+			return nil, ErrBack
+		}
 		if input != "" {
 			if input == HELP || input == HELP2 || input == HELP3 {
-				fmt.Printf("  Expected input: %s, continue with ⏎\n", f.Usage)
-				reader.ReadString('\n') // read entire line
+				fmt.Fprintf(s.out, "  Expected input: %s, continue with ⏎\n", f.Usage)
+				if _, err := readInput(reader); err != nil {
+					return nil, ErrCancelled
+				}
 			} else {
-				// User provided a value -> set it
-				if err := cmd.Flags().Set(f.Name, input); err != nil {
-					fmt.Printf("⚠️  Could not set flag %s: %v, continue with ⏎\n", f.Name, err)
-					reader.ReadString('\n') // read entire line
+				// User provided a value -> stage it
+				if err := validateFlagValue(f, input); err != nil {
+					s.printWarning(fmt.Sprintf("⚠️  Could not set flag %s: %v, continue with ⏎\n", f.Name, err))
+					if _, err := readInput(reader); err != nil {
+						return nil, ErrCancelled
+					}
 				} else {
-					fmt.Printf("\nSet value: --%s %s\n", f.Name, input)
-					histProvider.SaveHist(f.Name, input)
+					fmt.Fprintf(s.out, "\nSet value: --%s %s\n", f.Name, input)
+					s.histProvider.SaveHist(f.Name, input)
 					txtToIgnore = append(txtToIgnore, input)
-					setValue += flagTxt(f, input)
+					setValues = append(setValues, input)
 				}
 			}
 		} else {
 			break
 		}
 	}
-	*currentFlag++
-	return setValue
+	return setValues, nil
+}
+
+// renders the flag with the value for the program call. Flags with a
+// NoOptDefVal, e.g. bool flags, are rendered without value if it is the
+// NoOptDefVal, otherwise the value has to follow after '='.
+func flagTxt(f *pflag.Flag, value string, shorthand bool) string {
+	name := "--" + f.Name
+	if shorthand && f.Shorthand != "" && f.ShorthandDeprecated == "" {
+		name = "-" + f.Shorthand
+	}
+	switch {
+	case f.Value.Type() == "count":
+		return countTxt(f, value)
+	case f.NoOptDefVal != "" && value == f.NoOptDefVal:
+		return " " + name
+	case f.NoOptDefVal != "" || f.Value.Type() == "boolSlice":
+		return fmt.Sprintf(" --%s=%s", f.Name, escapeValue(value))
+	default:
+		return fmt.Sprintf(" %s %s", name, escapeValue(value))
+	}
+}
+
+// characters with a special meaning for the shell, values that contain
+// them are single-quoted in the program call
+const shellSpecialChars = "\"'\\$`&|;<>()[]{}*?!#~"
+
+func escapeValue(value string) string {
+	if strings.ContainsAny(value, shellSpecialChars) {
+		return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+	}
+	return strings.ReplaceAll(value, " ", "\\ ")
 }
 
-func flagTxt(f *pflag.Flag, value string) string {
-	escapedValue := strings.ReplaceAll(value, " ", "\\ ")
-	return fmt.Sprintf(" --%s %s", f.Name, escapedValue)
+// reads the next line of user input, the spaces around it are removed. It
+// returns an error if no more input is available, e.g. at the end of stdin.
+func readInput(reader *bufio.Reader) (string, error) {
+	input, err := reader.ReadString('\n')
+	input = trimInput(input)
+	if err != nil && input == "" {
+		return "", err
+	}
+	return input, nil
 }
 
 func trimInput(value string) string {
@@ -410,15 +857,3 @@ func trimInput(value string) string {
 		ret = tmp
 	}
 }
-
-// produces the fuzzy search input for interactive command selection
-func getOptionsFromCommands(cmds ...*cobra.Command) []string {
-	ret := make([]string, 0)
-	for _, cmd := range cmds {
-		if cmd.Use == "completion" {
-			continue
-		}
-		ret = append(ret, cmd.Use)
-	}
-	return ret
-}
@@ -7,3 +7,11 @@ var ReaderFactory = &readerFactory
 // exports to private selectionFactory var to mock the interactive tests
 // ... to enable testing with mocked input
 var SelectionFactory = &selectionFactory
+
+// exports to private multiSelectionFactory var to mock the interactive tests
+// ... to enable testing with mocked input
+var MultiSelectionFactory = &multiSelectionFactory
+
+// exports to private stdinIsTerminal var to mock the interactive tests
+// ... to enable testing without a terminal
+var StdinIsTerminal = &stdinIsTerminal
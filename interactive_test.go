@@ -2,9 +2,14 @@ package ic0bra_test
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/okieoth/ic0bra"
 	"github.com/spf13/cobra"
 )
@@ -13,14 +18,34 @@ func TestRunInteractive_NoSubCmds(t *testing.T) {
 	rootCmd := &cobra.Command{
 		Use:   "main",
 		Short: "first level",
-		Run: func(cmd *cobra.Command, args []string) {
-			_, err := ic0bra.RunInteractive(cmd)
-			if err == nil {
-				t.Errorf("expected error, because root cmd doesn't contain sub commands")
-			}
-		},
 	}
-	rootCmd.Execute()
+	_, err := ic0bra.RunInteractive(rootCmd)
+	if !errors.Is(err, ic0bra.ErrNoSubCommands) {
+		t.Errorf("expected error, because root cmd doesn't contain sub commands and can't run")
+	}
+}
+
+func TestRunInteractive_RunnableRootWithoutSubCmds(t *testing.T) {
+	var name string
+	rootCmd := &cobra.Command{
+		Use:   "main",
+		Short: "first level",
+		Run:   func(cmd *cobra.Command, args []string) {},
+	}
+	rootCmd.Flags().StringVar(&name, "name", "", "a string flag")
+	res, err := ic0bra.RunInteractive(rootCmd,
+		ic0bra.WithInput(strings.NewReader("\nflat\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+	)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if res.Command != rootCmd || res.CommandLine != "main --name flat" {
+		t.Errorf("unexpected result: %s", res.CommandLine)
+	}
+	if name != "flat" {
+		t.Errorf("seems name has wrong value: %s", name)
+	}
 }
 
 func TestRunInteractive_SubCmdChain(t *testing.T) {
@@ -53,31 +78,24 @@ func TestRunInteractive_SubCmdChain(t *testing.T) {
 			commandToCall:  "three",
 		},
 	}
-	origSelectionFactory := ic0bra.SelectionFactory
-	defer func() {
-		ic0bra.SelectionFactory = origSelectionFactory
-	}()
-
 	for _, test := range tests {
 		rootWasCalled := false
 		oneWasCalled := false
 		twoWasCalled := false
 		threeWasCalled := false
 
-		*ic0bra.SelectionFactory = func(promptString string, options []string) (string, error) {
-			return test.commandToCall, nil
-		}
-
 		rootCmd := &cobra.Command{
 			Use:   "main",
 			Short: "first level",
 			Run: func(cmd *cobra.Command, args []string) {
 				rootWasCalled = true
-				nextCmd, err := ic0bra.RunInteractive(cmd)
+				res, err := ic0bra.RunInteractive(cmd, ic0bra.WithExtendedSelector(func(promptString string, options []string, cfg ic0bra.SelectConfig) (string, error) {
+					return test.commandToCall, nil
+				}))
 				if err != nil {
 					t.Errorf("expected no error, got: %v", err)
 				}
-				nextCmd.Run(nextCmd, args)
+				res.Command.Run(res.Command, res.Args)
 			},
 		}
 		oneCmd := &cobra.Command{
@@ -351,10 +369,8 @@ func TestRunInteractive_InitFlags(t *testing.T) {
 			},
 		},
 	}
-	origSelectionFactory := ic0bra.SelectionFactory
 	origReaderFactory := ic0bra.ReaderFactory
 	defer func() {
-		ic0bra.SelectionFactory = origSelectionFactory
 		ic0bra.ReaderFactory = origReaderFactory
 	}()
 
@@ -363,9 +379,6 @@ func TestRunInteractive_InitFlags(t *testing.T) {
 		oneWasCalled := false
 		twoWasCalled := false
 
-		*ic0bra.SelectionFactory = func(promptString string, options []string) (string, error) {
-			return test.commandToCall, nil
-		}
 		*ic0bra.ReaderFactory = func() *bufio.Reader {
 			r := strings.NewReader(test.flagsInput)
 			return bufio.NewReader(r)
@@ -376,11 +389,13 @@ func TestRunInteractive_InitFlags(t *testing.T) {
 			Short: "first level",
 			Run: func(cmd *cobra.Command, args []string) {
 				rootWasCalled = true
-				nextCmd, err := ic0bra.RunInteractive(cmd)
+				res, err := ic0bra.RunInteractive(cmd, ic0bra.WithExtendedSelector(func(promptString string, options []string, cfg ic0bra.SelectConfig) (string, error) {
+					return test.commandToCall, nil
+				}))
 				if err != nil {
 					t.Errorf("expected no error, got: %v", err)
 				}
-				nextCmd.Run(nextCmd, args)
+				res.Command.Run(res.Command, res.Args)
 			},
 		}
 		oneCmd := &cobra.Command{
@@ -420,3 +435,90 @@ func TestRunInteractive_InitFlags(t *testing.T) {
 		}
 	}
 }
+
+func TestRunInteractive_ParentCmd(t *testing.T) {
+	root := &cobra.Command{Use: "main"}
+	aCmd := &cobra.Command{Use: "a"}
+	aCmd.AddCommand(&cobra.Command{Use: "a1", Run: func(cmd *cobra.Command, args []string) {}})
+	bCmd := &cobra.Command{Use: "b", Run: func(cmd *cobra.Command, args []string) {}}
+	root.AddCommand(aCmd, bCmd)
+
+	selections := []string{"a", "..", "b"}
+	offered := make([][]string, 0)
+	s := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			offered = append(offered, options)
+			ret := selections[0]
+			selections = selections[1:]
+			return ret, nil
+		}),
+	)
+	res, err := s.Run(root)
+	require.NoError(t, err)
+	assert.Equal(t, bCmd, res.Command)
+	require.Len(t, offered, 3)
+	assert.NotContains(t, offered[0], ic0bra.PARENT_CMD)
+	assert.Equal(t, []string{ic0bra.PARENT_CMD, "a1"}, offered[1])
+	assert.NotContains(t, offered[2], ic0bra.PARENT_CMD)
+}
+
+func TestRunInteractive_BackToPreviousFlag(t *testing.T) {
+	var alpha string
+	var beta int
+	var gamma []string
+	root := &cobra.Command{Use: "main"}
+	cmd := &cobra.Command{Use: "cmd", Run: func(cmd *cobra.Command, args []string) {}}
+	cmd.Flags().StringVar(&alpha, "alpha", "", "first flag")
+	cmd.Flags().IntVar(&beta, "beta", 0, "second flag")
+	cmd.Flags().StringSliceVar(&gamma, "gamma", []string{}, "third flag")
+	root.AddCommand(cmd)
+
+	inputs := []string{
+		"",   // start the flag collection
+		"<",  // already the first flag
+		"x",  // alpha
+		"<",  // back from beta to alpha
+		"y",  // alpha
+		"5",  // beta
+		"v1", // gamma
+		"<",  // back from gamma to beta
+		"6",  // beta
+		"",   // gamma
+		"",   // confirm
+	}
+	s := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader(strings.Join(inputs, "\n")+"\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "cmd", nil
+		}),
+	)
+	res, err := s.Run(root)
+	require.NoError(t, err)
+	assert.Equal(t, "y", alpha)
+	assert.Equal(t, 6, beta)
+	assert.Empty(t, gamma)
+	assert.False(t, cmd.Flags().Lookup("gamma").Changed)
+	assert.Equal(t, "main cmd --alpha y --beta 6", res.CommandLine)
+}
+
+func TestRunInteractive_BackToPreviousArg(t *testing.T) {
+	root := &cobra.Command{Use: "main"}
+	cmd := &cobra.Command{Use: "cmd <src> <dest>", Args: cobra.ExactArgs(2), Run: func(cmd *cobra.Command, args []string) {}}
+	root.AddCommand(cmd)
+	s := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("a\n<\nb\nc\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			if promptString == ic0bra.SELECT_SUB_CMD_PROMPT {
+				return "cmd", nil
+			}
+			return "", ic0bra.ErrCancelled
+		}),
+	)
+	res, err := s.Run(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, res.Args)
+}
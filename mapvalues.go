@@ -0,0 +1,109 @@
+package ic0bra
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// returns true for the flags with map values, e.g. `--label team=a`
+func isMapFlag(f *pflag.Flag) bool {
+	switch f.Value.Type() {
+	case "stringToString", "stringToInt", "stringToInt64":
+		return true
+	default:
+		return false
+	}
+}
+
+// renders a key/value pair in the notation pflag parses for a map flag
+func mapEntry(key, value string) string {
+	ret := key + "=" + value
+	if strings.Count(ret, "=") > 1 && strings.ContainsAny(ret, `,"`) {
+		// pflag reads entries with more than one '=' as csv
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{ret})
+		w.Flush()
+		ret = strings.TrimSuffix(buf.String(), "\n")
+	}
+	return ret
+}
+
+// returns the entries of a map flag value in the notation of mapEntry,
+// pflag renders the value like `[a=1,b=2]`
+func mapEntries(value string) []string {
+	ret := listValues(value)
+	for i, e := range ret {
+		k, v, _ := strings.Cut(e, "=")
+		ret[i] = mapEntry(k, v)
+	}
+	return ret
+}
+
+// MapEntries asks for the entries of a map flag, first the key then the
+// value, until an empty key is given. `-key` removes an entry.
+func (p *FlagPrompt) MapEntries() ([]string, error) {
+	keys := make([]string, 0)
+	values := make(map[string]string)
+	fmt.Fprintf(p.Out(), "\n[%d/%d] --%s %s\nkey/value pairs: %s\n", p.Pos, p.Total, p.Flag.Name, p.Hint, p.Flag.Usage)
+	for {
+		if len(keys) > 0 {
+			entries := make([]string, 0, len(keys))
+			for _, k := range keys {
+				entries = append(entries, k+"="+values[k])
+			}
+			fmt.Fprintf(p.Out(), "  current: %s\n", strings.Join(entries, ", "))
+		}
+		fmt.Fprintf(p.Out(), "key (empty to finish, '-key' to remove an entry): ")
+		key, err := p.ReadLine()
+		if err != nil {
+			return nil, ErrCancelled
+		}
+		if key == BACK {
+			return nil, ErrBack
+		}
+		if key == "" {
+			if len(keys) == 0 && p.Required {
+				p.Warn(fmt.Sprintf("⚠️  Flag %s is required, so input is needed!\n", p.Flag.Name))
+				continue
+			}
+			break
+		}
+		if name, ok := strings.CutPrefix(key, "-"); ok {
+			if !slices.Contains(keys, name) {
+				p.Warn(fmt.Sprintf("⚠️  There is no entry with the key %s\n", name))
+				continue
+			}
+			keys = slices.DeleteFunc(keys, func(k string) bool { return k == name })
+			delete(values, name)
+			continue
+		}
+		if strings.Contains(key, "=") {
+			p.Warn("⚠️  The key must not contain '='\n")
+			continue
+		}
+		fmt.Fprintf(p.Out(), "value for %s: ", key)
+		value, err := p.ReadLine()
+		if err != nil {
+			return nil, ErrCancelled
+		}
+		if err := p.Validate(mapEntry(key, value)); err != nil {
+			p.Warn(fmt.Sprintf("⚠️  Could not set flag %s: %v\n", p.Flag.Name, err))
+			continue
+		}
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+	ret := make([]string, 0, len(keys))
+	for _, k := range keys {
+		ret = append(ret, mapEntry(k, values[k]))
+	}
+	return ret, nil
+}
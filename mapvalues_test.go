@@ -0,0 +1,99 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+func runMapTest(t *testing.T, root *cobra.Command, input string) *ic0bra.InteractiveResult {
+	res, err := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader(input)),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "run", nil
+		}),
+		ic0bra.WithoutConfirm(),
+	).Run(root)
+	require.NoError(t, err)
+	return res
+}
+
+func TestMapFlags(t *testing.T) {
+	var labels map[string]string
+	var limits map[string]int
+	root := &cobra.Command{Use: "main"}
+	runCmd := &cobra.Command{Use: "run", Run: func(cmd *cobra.Command, args []string) {}}
+	runCmd.Flags().StringToStringVar(&labels, "label", map[string]string{"a": "1"}, "the labels")
+	runCmd.Flags().StringToIntVar(&limits, "limits", map[string]int{}, "the limits")
+	root.AddCommand(runCmd)
+
+	input := "\nteam\na\nenv\ndev\n-team\n-unknown\nowner\nx y\nexpr\na=b,c\n\ncpu\ntwo\ncpu\n2\n\n"
+	res := runMapTest(t, root, input)
+	assert.Equal(t, `main run --label env=dev --label owner=x\ y --label '"expr=a=b,c"' --limits cpu=2`, res.CommandLine)
+	assert.Equal(t, map[string]string{"env": "dev", "owner": "x y", "expr": "a=b,c"}, labels)
+	assert.Equal(t, map[string]int{"cpu": 2}, limits)
+
+	// map flags can't be reset, the next run keeps their entries
+	res.ResetFlags()
+	assert.Equal(t, map[string]string{"env": "dev", "owner": "x y", "expr": "a=b,c"}, labels)
+	assert.True(t, runCmd.Flags().Changed("label"))
+	res = runMapTest(t, root, "\n")
+	assert.Equal(t, `main run --label env=dev --label '"expr=a=b,c"' --label owner=x\ y --limits cpu=2`, res.CommandLine)
+}
+
+func TestMapFlags_SetOnCommandLine(t *testing.T) {
+	var labels map[string]string
+	root := &cobra.Command{Use: "main"}
+	runCmd := &cobra.Command{Use: "run", Run: func(cmd *cobra.Command, args []string) {}}
+	runCmd.Flags().StringToStringVar(&labels, "label", map[string]string{"a": "1"}, "the labels")
+	root.AddCommand(runCmd)
+	// the entries set on the command line are kept
+	require.NoError(t, runCmd.Flags().Set("label", "team=c"))
+	res := runMapTest(t, root, "")
+	assert.Equal(t, "main run --label team=c", res.CommandLine)
+	assert.Equal(t, map[string]string{"team": "c"}, labels)
+}
+
+// custom pflag.Value that only fails on the flag itself, not on the value
+// the input is validated with
+type lockedValue struct {
+	locked bool
+}
+
+func (v *lockedValue) String() string { return "" }
+func (v *lockedValue) Type() string   { return "locked" }
+func (v *lockedValue) Set(s string) error {
+	if v.locked {
+		return errors.New("locked")
+	}
+	return nil
+}
+
+func TestMapFlags_AppliedLast(t *testing.T) {
+	var labels map[string]string
+	root := &cobra.Command{Use: "main"}
+	runCmd := &cobra.Command{Use: "run", Run: func(cmd *cobra.Command, args []string) {}}
+	runCmd.Flags().StringToStringVar(&labels, "label", map[string]string{}, "the labels")
+	runCmd.Flags().Var(&lockedValue{locked: true}, "lock", "fails to be set")
+	root.AddCommand(runCmd)
+	_, err := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\nteam\na\n\nx\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "run", nil
+		}),
+		ic0bra.WithoutConfirm(),
+	).Run(root)
+	assert.ErrorContains(t, err, "--lock")
+	// the failure of a flag asked later doesn't leave map entries behind
+	assert.Empty(t, labels)
+	assert.False(t, runCmd.Flags().Changed("label"))
+}
@@ -0,0 +1,87 @@
+package ic0bra
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// entries of the selection for flags with a NoOptDefVal
+const (
+	NOOPT_SET   = "set without value (%s)"
+	NOOPT_VALUE = "set with value"
+	NOOPT_SKIP  = ENUM_SKIP
+)
+
+// Count asks for the value of a count flag, e.g. `-vvv`, as a number
+func (p *FlagPrompt) Count() ([]string, error) {
+	for {
+		fmt.Fprintf(p.Out(), "\n[%d/%d] --%s: %s (current %s) [number]: ", p.Pos, p.Total, p.Flag.Name, p.Flag.Usage, p.Flag.Value.String())
+		input, err := p.ReadLine()
+		if err != nil {
+			return nil, ErrCancelled
+		}
+		if input == BACK {
+			return nil, ErrBack
+		}
+		if input == "" {
+			if !p.Required {
+				return nil, nil
+			}
+			p.Warn(fmt.Sprintf("⚠️  Flag %s is required, so input is needed!\n", p.Flag.Name))
+			continue
+		}
+		if n, err := strconv.Atoi(input); err != nil || n < 0 {
+			p.Warn(fmt.Sprintf("⚠️  Could not set flag %s: %s is no positive number\n", p.Flag.Name, input))
+			continue
+		}
+		fmt.Fprintf(p.Out(), "\nSet value: --%s %s\n", p.Flag.Name, input)
+		return []string{input}, nil
+	}
+}
+
+// OptionalValue asks for a flag with a NoOptDefVal, which can be set
+// without value, with a value or be skipped
+func (p *FlagPrompt) OptionalValue() ([]string, error) {
+	setWithout := fmt.Sprintf(NOOPT_SET, p.Flag.NoOptDefVal)
+	options := []string{setWithout, NOOPT_VALUE}
+	if !p.Required {
+		options = append(options, NOOPT_SKIP)
+	}
+	for {
+		selected, err := p.Select(fmt.Sprintf("[%d/%d] --%s %s: ", p.Pos, p.Total, p.Flag.Name, p.Hint), options)
+		if aborted(err) {
+			return nil, ErrBack
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch selected {
+		case setWithout:
+			fmt.Fprintf(p.Out(), "\nSet value: --%s\n", p.Flag.Name)
+			return []string{p.Flag.NoOptDefVal}, nil
+		case NOOPT_VALUE:
+			// without input the selection is shown again
+			text := *p
+			text.Required = false
+			values, err := text.Text()
+			if err != nil || len(values) > 0 {
+				return values, err
+			}
+		default:
+			return nil, nil
+		}
+	}
+}
+
+// renders a count flag as repeated shorthand, e.g. `-vvv`, or with the
+// number after '='
+func countTxt(f *pflag.Flag, value string) string {
+	n, err := strconv.Atoi(value)
+	if err == nil && n > 0 && f.Shorthand != "" && f.ShorthandDeprecated == "" {
+		return " -" + strings.Repeat(f.Shorthand, n)
+	}
+	return fmt.Sprintf(" --%s=%s", f.Name, value)
+}
@@ -0,0 +1,116 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+func newCountTestRoot(verbose, level *int) *cobra.Command {
+	root := &cobra.Command{Use: "main"}
+	runCmd := &cobra.Command{Use: "run", Args: cobra.NoArgs, Run: func(cmd *cobra.Command, args []string) {}}
+	runCmd.Flags().CountVar(level, "level", "a count flag without shorthand")
+	runCmd.Flags().CountVarP(verbose, "verbose", "v", "a count flag")
+	root.AddCommand(runCmd)
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	return root
+}
+
+func TestCountFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		verbose  int
+		level    int
+	}{
+		{name: "set", input: "\n2\n3\n", expected: "main run --level=2 -vvv", verbose: 3, level: 2},
+		{name: "invalid input", input: "\n-1\nmany\n\n1\n", expected: "main run -v", verbose: 1},
+		{name: "skipped", input: "\n\n\n", expected: "main run"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var verbose, level int
+			root := newCountTestRoot(&verbose, &level)
+			var out bytes.Buffer
+			res, err := ic0bra.New(
+				ic0bra.WithInput(strings.NewReader(test.input)),
+				ic0bra.WithOutput(&out),
+				ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+					return "run", nil
+				}),
+				ic0bra.WithoutConfirm(),
+			).Run(root)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, res.CommandLine)
+			assert.Equal(t, test.verbose, verbose)
+			assert.Equal(t, test.level, level)
+
+			// cobra parses the rendered program call to the same values
+			var parsedVerbose, parsedLevel int
+			root = newCountTestRoot(&parsedVerbose, &parsedLevel)
+			root.SetArgs(strings.Fields(res.CommandLine)[1:])
+			require.NoError(t, root.Execute())
+			assert.Equal(t, test.verbose, parsedVerbose)
+			assert.Equal(t, test.level, parsedLevel)
+		})
+	}
+}
+
+func TestOptionalValueFlags_Required(t *testing.T) {
+	root := &cobra.Command{Use: "main"}
+	runCmd := &cobra.Command{Use: "run", Run: func(cmd *cobra.Command, args []string) {}}
+	runCmd.Flags().String("color", "never", "a flag with NoOptDefVal")
+	runCmd.Flags().Lookup("color").NoOptDefVal = "auto"
+	runCmd.MarkFlagRequired("color")
+	root.AddCommand(runCmd)
+	var offered []string
+	selections := []string{"run", ic0bra.NOOPT_VALUE, "set without value (auto)"}
+	res, err := ic0bra.New(
+		// no value entered returns to the selection
+		ic0bra.WithInput(strings.NewReader("\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			offered = options
+			selected := selections[0]
+			selections = selections[1:]
+			return selected, nil
+		}),
+		ic0bra.WithoutConfirm(),
+	).Run(root)
+	require.NoError(t, err)
+	assert.Equal(t, "main run --color", res.CommandLine)
+	assert.Equal(t, []string{"set without value (auto)", ic0bra.NOOPT_VALUE}, offered)
+}
+
+func TestOptionalValueFlags_SelectorError(t *testing.T) {
+	root := &cobra.Command{Use: "main"}
+	runCmd := &cobra.Command{Use: "run", Run: func(cmd *cobra.Command, args []string) {}}
+	runCmd.Flags().String("color", "never", "a flag with NoOptDefVal")
+	runCmd.Flags().Lookup("color").NoOptDefVal = "auto"
+	root.AddCommand(runCmd)
+	errSelector := errors.New("no terminal")
+	calls := 0
+	_, err := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			if promptString == ic0bra.SELECT_SUB_CMD_PROMPT {
+				return "run", nil
+			}
+			calls++
+			require.Less(t, calls, 2, "the flag is asked again")
+			return "", errSelector
+		}),
+		ic0bra.WithoutConfirm(),
+	).Run(root)
+	assert.ErrorIs(t, err, errSelector)
+}
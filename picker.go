@@ -0,0 +1,77 @@
+package ic0bra
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+const PICK_FLAGS_PROMPT = "Select the optional flags to set (TAB to mark): "
+
+// WithFlagPicker changes the flag collection. Instead of asking for every
+// flag in order, the required flags are asked first and then the user picks
+// the optional flags to set from a list.
+func WithFlagPicker() Option {
+	return func(s *Session) {
+		s.pickFlags = true
+	}
+}
+
+// lets the user select the optional flags to set, it returns the indexes
+// of the picked flags
+func (fc *flagCollector) pickOptionalFlags(optional []int) []int {
+	if len(optional) == 0 {
+		return optional
+	}
+	options := make([]string, 0, len(optional))
+	indexes := make(map[string]int)
+	for _, i := range optional {
+		label := "--" + fc.flags[i].flag.Name
+		options = append(options, label)
+		indexes[label] = i
+	}
+	selected, err := fc.s.multiSelectFn()(PICK_FLAGS_PROMPT, options, SelectConfig{
+		Preview: func(idx int) string {
+			return flagPreview(fc.flags[optional[idx]].flag)
+		},
+	})
+	if err != nil {
+		return []int{}
+	}
+	picked := make(map[int]bool)
+	for _, sel := range selected {
+		if i, ok := indexes[sel]; ok {
+			picked[i] = true
+		}
+	}
+	// keep the order of the flags
+	ret := make([]int, 0, len(picked))
+	for _, i := range optional {
+		if picked[i] {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+// returns the description of the flag shown in the preview window
+func flagPreview(f *pflag.Flag) string {
+	var sb strings.Builder
+	sb.WriteString("--" + f.Name)
+	if f.Shorthand != "" {
+		sb.WriteString(", -" + f.Shorthand)
+	}
+	fmt.Fprintf(&sb, "\n\ntype: %s\n", f.Value.Type())
+	if f.DefValue != "" {
+		fmt.Fprintf(&sb, "default: %s\n", f.DefValue)
+	}
+	if allowed := enumValues(f); allowed != nil {
+		fmt.Fprintf(&sb, "allowed: %s\n", strings.Join(allowed, ", "))
+	}
+	if f.Deprecated != "" {
+		fmt.Fprintf(&sb, "deprecated: %s\n", f.Deprecated)
+	}
+	fmt.Fprintf(&sb, "\n%s\n", f.Usage)
+	return sb.String()
+}
@@ -0,0 +1,70 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+func newPickerTestRoot() *cobra.Command {
+	root := &cobra.Command{Use: "main"}
+	cmd := &cobra.Command{Use: "cmd", Run: func(cmd *cobra.Command, args []string) {}}
+	cmd.Flags().String("a", "", "the a flag")
+	cmd.Flags().String("b", "", "the b flag")
+	cmd.Flags().IntP("c", "x", 3, "the c flag")
+	cmd.Flags().String("req", "", "the required flag")
+	cmd.MarkFlagRequired("req")
+	root.AddCommand(cmd)
+	return root
+}
+
+func TestFlagPicker(t *testing.T) {
+	root := newPickerTestRoot()
+	var offered []string
+	var previews []string
+	s := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\nr\n1\n7\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "cmd", nil
+		}),
+		ic0bra.WithFlagPicker(),
+		ic0bra.WithMultiSelector(func(promptString string, options []string, cfg ic0bra.SelectConfig) ([]string, error) {
+			offered = options
+			for i := range options {
+				previews = append(previews, cfg.Preview(i))
+			}
+			return []string{"--c", "--a"}, nil
+		}),
+	)
+	res, err := s.Run(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--a", "--b", "--c"}, offered)
+	require.Len(t, previews, 3)
+	assert.Equal(t, "--c, -x\n\ntype: int\ndefault: 3\n\nthe c flag\n", previews[2])
+	assert.Equal(t, "main cmd --a 1 --c 7 --req r", res.CommandLine)
+}
+
+func TestFlagPicker_NothingPicked(t *testing.T) {
+	s := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\nr\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "cmd", nil
+		}),
+		ic0bra.WithFlagPicker(),
+		ic0bra.WithMultiSelector(func(promptString string, options []string, cfg ic0bra.SelectConfig) ([]string, error) {
+			return nil, errors.New("aborted")
+		}),
+	)
+	res, err := s.Run(newPickerTestRoot())
+	require.NoError(t, err)
+	assert.Equal(t, "main cmd --req r", res.CommandLine)
+}
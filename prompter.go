@@ -0,0 +1,179 @@
+package ic0bra
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ErrBack is returned by a FlagPrompter when the user wants to step back
+// to the previous flag
+var ErrBack = errors.New("back to the previous input")
+
+// FlagPrompt is the context of the prompt for one flag
+type FlagPrompt struct {
+	// the command the flag is asked for
+	Cmd  *cobra.Command
+	Flag *pflag.Flag
+	// true if the prompt must not finish without a value
+	Required bool
+	// hint for the prompt, e.g. "(required)" or "(default 5)"
+	Hint string
+	// position of the flag in the collection and the number of flags, for
+	// the [pos/total] display
+	Pos   int
+	Total int
+
+	s      *Session
+	reader *bufio.Reader
+}
+
+// FlagPrompter asks for the value of a flag. It returns the values to set,
+// repeatable flags can have more than one, no values to skip the flag and
+// ErrBack to step back to the previous flag. ErrCancelled or io.EOF cancel
+// the run. The values are set on the flag after the user confirmed the
+// program call.
+type FlagPrompter interface {
+	Prompt(p *FlagPrompt) ([]string, error)
+}
+
+// FlagPrompterFunc allows to use a function as FlagPrompter
+type FlagPrompterFunc func(p *FlagPrompt) ([]string, error)
+
+func (f FlagPrompterFunc) Prompt(p *FlagPrompt) ([]string, error) {
+	return f(p)
+}
+
+var (
+	flagPromptersMutex sync.RWMutex
+	// the prompters for all sessions, the key is the pflag type
+	flagPrompters = make(map[string]FlagPrompter)
+)
+
+// RegisterFlagPrompter sets the prompter for the flags with the given
+// pflag type, e.g. "duration" or the result of the Type method of a custom
+// pflag.Value. It is used by all sessions, WithFlagPrompter overrides it
+// for a single session. A nil prompter removes the registration.
+func RegisterFlagPrompter(valueType string, p FlagPrompter) {
+	flagPromptersMutex.Lock()
+	defer flagPromptersMutex.Unlock()
+	if p == nil {
+		delete(flagPrompters, valueType)
+		return
+	}
+	flagPrompters[valueType] = p
+}
+
+// WithFlagPrompter sets the prompter for the flags with the given pflag type
+// in the session
+func WithFlagPrompter(valueType string, p FlagPrompter) Option {
+	return func(s *Session) {
+		if s.prompters == nil {
+			s.prompters = make(map[string]FlagPrompter)
+		}
+		s.prompters[valueType] = p
+	}
+}
+
+// the prompters for the pflag types that need more than a text prompt
+var defaultFlagPrompters = map[string]FlagPrompter{
+	"bool":           FlagPrompterFunc((*FlagPrompt).YesNo),
+	"boolSlice":      FlagPrompterFunc((*FlagPrompt).RepeatedYesNo),
+	"stringToString": FlagPrompterFunc((*FlagPrompt).MapEntries),
+	"stringToInt":    FlagPrompterFunc((*FlagPrompt).MapEntries),
+	"stringToInt64":  FlagPrompterFunc((*FlagPrompt).MapEntries),
+	"count":          FlagPrompterFunc((*FlagPrompt).Count),
+}
+
+// examples of the expected input for the pflag types without own prompter
+var flagTypeHints = map[string]string{
+	"duration":    "e.g. 1h30m, 90s",
+	"ip":          "e.g. 192.168.0.1",
+	"ipMask":      "e.g. 255.255.255.0",
+	"ipNet":       "e.g. 10.0.0.0/8",
+	"bytesHex":    "hex encoded, e.g. 0aff",
+	"bytesBase64": "base64 encoded",
+}
+
+// returns the prompter for the flag. Besides the registered and the default
+// ones, the flags with a NoOptDefVal can be set without value, the flags
+// with slice values are asked for repeatedly and all other flags by a text
+// prompt.
+func (s *Session) flagPrompter(f *pflag.Flag) FlagPrompter {
+	if p, ok := s.prompters[f.Value.Type()]; ok {
+		return p
+	}
+	flagPromptersMutex.RLock()
+	p, ok := flagPrompters[f.Value.Type()]
+	flagPromptersMutex.RUnlock()
+	if ok {
+		return p
+	}
+	if enumValues(f) != nil {
+		return FlagPrompterFunc((*FlagPrompt).Enum)
+	}
+	if p, ok := defaultFlagPrompters[f.Value.Type()]; ok {
+		return p
+	}
+	if f.NoOptDefVal != "" {
+		return FlagPrompterFunc((*FlagPrompt).OptionalValue)
+	}
+	if isRepeatableFlag(f) {
+		return FlagPrompterFunc((*FlagPrompt).RepeatedText)
+	}
+	return FlagPrompterFunc((*FlagPrompt).Text)
+}
+
+// Text asks for the value of the flag as free text, the proposals of a
+// registered completion function and the history are offered
+func (p *FlagPrompt) Text() ([]string, error) {
+	hint := p.Hint
+	if example, ok := flagTypeHints[p.Flag.Value.Type()]; ok {
+		hint = fmt.Sprintf("%s [%s]", hint, example)
+	}
+	if p.s.histProvider != nil {
+		return p.s.collectFlagInputWithHist(p.Cmd, p.Flag, p.Required, hint, p.reader, p.Total, p.Pos)
+	}
+	return p.s.collectFlagInput(p.Cmd, p.Flag, p.Required, hint, p.reader, p.Total, p.Pos)
+}
+
+// RepeatedText asks for the values of the flag as free text until an empty
+// input is given
+func (p *FlagPrompt) RepeatedText() ([]string, error) {
+	if p.s.histProvider != nil {
+		return p.s.collectRepeatedFlagInputWithHist(p.Cmd, p.Flag, p.Hint, p.reader, p.Total, p.Pos)
+	}
+	return p.s.collectRepeatedFlagInput(p.Cmd, p.Flag, p.Hint, p.reader, p.Total, p.Pos)
+}
+
+// Out returns the stream for prompts and messages
+func (p *FlagPrompt) Out() io.Writer {
+	return p.s.out
+}
+
+// ReadLine reads the next line of user input, the spaces around it are
+// removed. It returns io.EOF at the end of the input.
+func (p *FlagPrompt) ReadLine() (string, error) {
+	return readInput(p.reader)
+}
+
+// Select lets the user select one of the options with the selector of the
+// session
+func (p *FlagPrompt) Select(promptString string, options []string) (string, error) {
+	return p.s.selectFn()(promptString, options)
+}
+
+// Validate checks if the value can be set on the flag, without changing it
+func (p *FlagPrompt) Validate(value string) error {
+	return validateFlagValue(p.Flag, value)
+}
+
+// Warn shows a warning to the user
+func (p *FlagPrompt) Warn(msg string) {
+	p.s.printWarning(msg)
+}
@@ -0,0 +1,128 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+// custom pflag.Value for versions like 1.2.3
+type semver struct {
+	value string
+}
+
+func (v *semver) String() string { return v.value }
+func (v *semver) Type() string   { return "semver" }
+func (v *semver) Set(s string) error {
+	var major, minor, patch int
+	if _, err := fmt.Sscanf(s, "%d.%d.%d", &major, &minor, &patch); err != nil {
+		return fmt.Errorf("not a version: %s", s)
+	}
+	v.value = s
+	return nil
+}
+
+func newPrompterTestRoot(version *semver, timeout *time.Duration) *cobra.Command {
+	root := &cobra.Command{Use: "main"}
+	runCmd := &cobra.Command{Use: "run", Run: func(cmd *cobra.Command, args []string) {}}
+	runCmd.Flags().DurationVar(timeout, "timeout", 0, "the timeout")
+	runCmd.Flags().Var(version, "version", "the version")
+	root.AddCommand(runCmd)
+	return root
+}
+
+func TestFlagPrompter_Session(t *testing.T) {
+	var version semver
+	var timeout time.Duration
+	root := newPrompterTestRoot(&version, &timeout)
+	calls := 0
+	var out bytes.Buffer
+	res, err := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\n5s\n1m\n\n")),
+		ic0bra.WithOutput(&out),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "run", nil
+		}),
+		ic0bra.WithFlagPrompter("semver", ic0bra.FlagPrompterFunc(func(p *ic0bra.FlagPrompt) ([]string, error) {
+			calls++
+			assert.Equal(t, "version", p.Flag.Name)
+			assert.Equal(t, 2, p.Total)
+			if calls == 1 {
+				// back to the timeout
+				return nil, ic0bra.ErrBack
+			}
+			require.Error(t, p.Validate("latest"))
+			require.NoError(t, p.Validate("1.2.3"))
+			return []string{"1.2.3"}, nil
+		})),
+	).Run(root)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "main run --timeout 1m --version 1.2.3", res.CommandLine)
+	assert.Equal(t, "1.2.3", version.String())
+	assert.Equal(t, time.Minute, timeout)
+	assert.Contains(t, out.String(), "e.g. 1h30m")
+}
+
+func TestFlagPrompter_Registry(t *testing.T) {
+	ic0bra.RegisterFlagPrompter("semver", ic0bra.FlagPrompterFunc(func(p *ic0bra.FlagPrompt) ([]string, error) {
+		input, err := p.ReadLine()
+		// e.g. v1.0.0
+		return []string{strings.TrimPrefix(input, "v")}, err
+	}))
+	defer ic0bra.RegisterFlagPrompter("semver", nil)
+	var version semver
+	var timeout time.Duration
+	root := newPrompterTestRoot(&version, &timeout)
+	sessionPrompter := ic0bra.FlagPrompterFunc(func(p *ic0bra.FlagPrompt) ([]string, error) {
+		return []string{"2.0.0"}, nil
+	})
+	for _, test := range []struct {
+		opts     []ic0bra.Option
+		expected string
+	}{
+		{expected: "main run --version 1.0.0"},
+		// the session prompter overrides the registered one
+		{opts: []ic0bra.Option{ic0bra.WithFlagPrompter("semver", sessionPrompter)}, expected: "main run --version 2.0.0"},
+	} {
+		opts := append([]ic0bra.Option{
+			ic0bra.WithInput(strings.NewReader("\n\nv1.0.0\n\n")),
+			ic0bra.WithOutput(&bytes.Buffer{}),
+			ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+				return "run", nil
+			}),
+			ic0bra.WithoutConfirm(),
+		}, test.opts...)
+		res, err := ic0bra.New(opts...).Run(root)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, res.CommandLine)
+		res.ResetFlags()
+	}
+}
+
+func TestFlagPrompter_EndOfInput(t *testing.T) {
+	var version semver
+	var timeout time.Duration
+	res, err := ic0bra.New(
+		ic0bra.WithFlagPrompter("semver", ic0bra.FlagPrompterFunc(func(p *ic0bra.FlagPrompt) ([]string, error) {
+			input, err := p.ReadLine()
+			return []string{input}, err
+		})),
+		// the input ends at the prompt for the version
+		ic0bra.WithInput(strings.NewReader("\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "run", nil
+		}),
+	).Run(newPrompterTestRoot(&version, &timeout))
+	assert.ErrorIs(t, err, ic0bra.ErrCancelled)
+	assert.True(t, res.Cancelled)
+}
@@ -0,0 +1,52 @@
+package ic0bra
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// returns the names of the required flags of the command that are not set
+func missingRequiredFlags(cmd *cobra.Command) []string {
+	ret := make([]string, 0)
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if isFlagRequired(f) && !f.Changed {
+			ret = append(ret, f.Name)
+		}
+	})
+	return ret
+}
+
+// asks only for the missing required flags and args of the command. The
+// flags that are already set and the given args are kept.
+func (s *Session) collectMissingInput(cmd *cobra.Command, args []string) (*InteractiveResult, error) {
+	reader := s.newReader()
+	_, txt := getCommandChain(cmd)
+	// the set flags are kept by the flag collector
+	inGroups := unsatisfiedGroupFlags(cmd)
+	flags := slices.DeleteFunc(getFlagsToAsk(cmd), func(ftc flagToAsk) bool {
+		return !isFlagRequired(ftc.flag) && !ftc.flag.Changed && !inGroups[ftc.flag.Name]
+	})
+	if missing := missingRequiredFlags(cmd); len(missing) > 0 {
+		s.printWarning(fmt.Sprintf("\n⚠️  Required flag(s) not set: --%s\n", strings.Join(missing, ", --")))
+	}
+	if err := cmd.ValidateFlagGroups(); err != nil && len(inGroups) > 0 {
+		s.printWarning(fmt.Sprintf("\n⚠️  %v\n", err))
+	}
+	args = slices.Clone(args)
+	if !argsValid(cmd, args) {
+		s.printWarning(fmt.Sprintf("\n⚠️  Invalid arguments: %v\n", validateArgs(cmd, args)))
+		if !argsReachable(cmd, args) {
+			// the given args can't be completed
+			args = []string{}
+		}
+	}
+	fc := s.newFlagCollector(reader, flags)
+	if err := fc.collectAll(txt); err != nil {
+		return cancelledResult(err)
+	}
+	return s.completeInput(cmd, fc, args, reader)
+}
@@ -0,0 +1,90 @@
+package ic0bra
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// returned when the user canceled the interactive run
+var ErrCancelled = errors.New("interactive run canceled")
+
+// returned when the wizard is started on a command without sub commands,
+// that can't run
+var ErrNoSubCommands = errors.New("command has no sub commands")
+
+// returned when the command executed by the wizard starts the wizard again,
+// e.g. a root command without sub commands that calls ExecuteInteractive in
+// its Run function
+var ErrReentered = errors.New("interactive run started by the executed command")
+
+// InteractiveResult describes the outcome of an interactive run
+type InteractiveResult struct {
+	// the selected command
+	Command *cobra.Command
+	// names of the commands from the root to the selected command
+	CommandPath []string
+	// the collected flag values, the key is the flag name
+	Flags map[string]FlagValue
+	// positional arguments for the selected command
+	Args []string
+	// the resulting program call
+	CommandLine string
+	// true if the user canceled the run
+	Cancelled bool
+}
+
+// FlagValue holds the input collected for one flag
+type FlagValue struct {
+	// the pflag type of the flag, e.g. "int" or "stringSlice"
+	Type string
+	// the entered values, repeatable flags can have more than one
+	Values []string
+}
+
+// collected input for one flag, kept in the order of the input
+type collectedFlag struct {
+	flag   *pflag.Flag
+	values []string
+}
+
+// builds the result for the selected command with its command path, the
+// collected flag values, the args and the resulting program call. shorthands
+// renders the flags in the program call in the short form.
+func newInteractiveResult(cmd *cobra.Command, collected []collectedFlag, args []string, shorthands bool) *InteractiveResult {
+	ret := &InteractiveResult{
+		Command: cmd,
+		Flags:   make(map[string]FlagValue),
+		Args:    args,
+	}
+	if ret.Args == nil {
+		ret.Args = []string{}
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		ret.CommandPath = append([]string{c.Name()}, ret.CommandPath...)
+	}
+	var sb strings.Builder
+	sb.WriteString(strings.Join(ret.CommandPath, " "))
+	for _, c := range collected {
+		ret.Flags[c.flag.Name] = FlagValue{
+			Type:   c.flag.Value.Type(),
+			Values: c.values,
+		}
+		for _, v := range c.values {
+			sb.WriteString(flagTxt(c.flag, v, shorthands))
+		}
+	}
+	sb.WriteString(argsTxt(ret.Args))
+	ret.CommandLine = sb.String()
+	return ret
+}
+
+// ResetFlags sets the flags of the selected command and its parents back to
+// their defaults
+func (r *InteractiveResult) ResetFlags() {
+	if r.Command != nil {
+		ResetFlags(r.Command)
+	}
+}
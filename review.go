@@ -0,0 +1,214 @@
+package ic0bra
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const REVIEW_PROMPT = "Review the input, select an entry to change it: "
+
+// entries of the review screen
+const REVIEW_CONFIRM = "✔ confirm"
+const REVIEW_CANCEL = "✘ cancel"
+const REVIEW_ADD_FLAG = "+ add a skipped flag"
+const REVIEW_ADD_ARG = "+ add an argument"
+
+// actions for a selected entry of the review screen
+const REVIEW_EDIT = "edit"
+const REVIEW_CLEAR = "clear"
+
+// WithReview replaces the final yes/no question by a review screen. It lists
+// the collected flags and args, which can be edited or cleared, and allows
+// to add skipped flags before the input is confirmed.
+func WithReview() Option {
+	return func(s *Session) {
+		s.review = true
+	}
+}
+
+// shows the review screen until the user confirms or cancels. It returns
+// the possibly changed args and true if the input was confirmed.
+func (s *Session) reviewInput(cmd *cobra.Command, fc *flagCollector, args []string, reader *bufio.Reader) ([]string, bool) {
+	// in the review only the set flags exclude the other flags of a mutually
+	// exclusive group, not the choices of the collection
+	fc.choices = make(map[string]flagChoice)
+	for {
+		result := newInteractiveResult(cmd, fc.result(), args, s.shorthands)
+		s.printInfo("\nresulting program call:\n\n")
+		s.theme.CommandLine.Fprintf(s.out, "  %s\n", result.CommandLine)
+
+		options := []string{REVIEW_CONFIRM}
+		// an error of an action, e.g. the end of the input, cancels the review
+		actions := make(map[string]func() error)
+		for i, ftc := range fc.flags {
+			var label string
+			if len(fc.collected[i]) > 0 {
+				label = fmt.Sprintf("--%s: %s", ftc.flag.Name, strings.Join(fc.collected[i], ", "))
+			} else if isFlagRequired(ftc.flag) && !ftc.flag.Changed {
+				label = fmt.Sprintf("--%s: (required, not set)", ftc.flag.Name)
+			} else {
+				continue
+			}
+			options = append(options, label)
+			actions[label] = func() error { return s.reviewFlag(fc, i) }
+		}
+		for i, a := range args {
+			label := fmt.Sprintf("[arg %d] <%s>: %s", i+1, argName(cmd, i), a)
+			options = append(options, label)
+			actions[label] = func() error {
+				var err error
+				args, err = s.reviewArg(cmd, args, i, reader)
+				return err
+			}
+		}
+		if len(fc.skippedFlags()) > 0 {
+			options = append(options, REVIEW_ADD_FLAG)
+			actions[REVIEW_ADD_FLAG] = func() error { return s.addSkippedFlag(fc) }
+		}
+		if hasPositionalArgs(cmd) && moreArgsPossible(cmd, args) {
+			options = append(options, REVIEW_ADD_ARG)
+			actions[REVIEW_ADD_ARG] = func() error {
+				var err error
+				args, err = s.collectArgs(cmd, args, reader)
+				return err
+			}
+		}
+		options = append(options, REVIEW_CANCEL)
+
+		selected, err := s.selectFn()(REVIEW_PROMPT, options)
+		if err != nil || selected == REVIEW_CANCEL {
+			return args, false
+		}
+		if selected == REVIEW_CONFIRM {
+			if missing := missingInput(cmd, fc, args); missing != "" {
+				s.printWarning(fmt.Sprintf("⚠️  Input is incomplete: %s\n", missing))
+				continue
+			}
+			return args, true
+		}
+		if action, ok := actions[selected]; ok {
+			if err := action(); err != nil {
+				return args, false
+			}
+		}
+	}
+}
+
+// returns a description of the missing input, empty if nothing is missing
+func missingInput(cmd *cobra.Command, fc *flagCollector, args []string) string {
+	for i, ftc := range fc.flags {
+		if len(fc.collected[i]) == 0 && isFlagRequired(ftc.flag) && !ftc.flag.Changed {
+			return fmt.Sprintf("flag --%s is required", ftc.flag.Name)
+		}
+	}
+	for _, g := range fc.groups {
+		set := 0
+		for _, n := range g.names {
+			if fc.hasValue(n) {
+				set++
+			}
+		}
+		switch {
+		case g.kind == mutuallyExclusiveAnnotation && set > 1:
+			return fmt.Sprintf("only one of the flags %s can be set", g.flagList())
+		case g.kind == requiredTogetherAnnotation && set > 0 && set < len(g.names):
+			return fmt.Sprintf("the flags %s have to be set together", g.flagList())
+		case g.kind == oneRequiredAnnotation && set == 0:
+			return fmt.Sprintf("one of the flags %s is required", g.flagList())
+		}
+	}
+	if !argsValid(cmd, args) {
+		return fmt.Sprintf("invalid arguments: %v", validateArgs(cmd, args))
+	}
+	return ""
+}
+
+// lets the user edit or clear the input of the flag with the given index
+func (s *Session) reviewFlag(fc *flagCollector, i int) error {
+	f := fc.flags[i].flag
+	selected, err := s.selectFn()(fmt.Sprintf("--%s: ", f.Name), []string{REVIEW_EDIT, REVIEW_CLEAR})
+	if err != nil {
+		return nil
+	}
+	switch selected {
+	case REVIEW_EDIT:
+		prevValues := fc.collected[i]
+		if err := fc.ask(i, 1, 1); err != nil {
+			// keep the previous input
+			fc.collected[i] = prevValues
+			if !errors.Is(err, ErrBack) {
+				return err
+			}
+		}
+	case REVIEW_CLEAR:
+		fc.clear(i)
+	}
+	return nil
+}
+
+// lets the user edit or clear the positional arg with the given index
+func (s *Session) reviewArg(cmd *cobra.Command, args []string, i int, reader *bufio.Reader) ([]string, error) {
+	selected, err := s.selectFn()(fmt.Sprintf("[arg %d] <%s>: ", i+1, argName(cmd, i)), []string{REVIEW_EDIT, REVIEW_CLEAR})
+	if err != nil {
+		return args, nil
+	}
+	switch selected {
+	case REVIEW_EDIT:
+		input, ok := s.askArg(cmd, args, i, "(leave empty to keep the value)", reader)
+		if !ok {
+			return args, ErrCancelled
+		}
+		if input == "" || input == BACK {
+			return args, nil
+		}
+		newArgs := slices.Clone(args)
+		newArgs[i] = input
+		if !argsReachable(cmd, newArgs) {
+			s.printWarning(fmt.Sprintf("⚠️  Invalid argument: %v\n", validateArgs(cmd, newArgs)))
+			return args, nil
+		}
+		return newArgs, nil
+	case REVIEW_CLEAR:
+		return slices.Delete(slices.Clone(args), i, i+1), nil
+	}
+	return args, nil
+}
+
+// returns the indexes of the flags without input, that can be added. The
+// flags excluded by a set flag of a mutually exclusive group are left out.
+func (fc *flagCollector) skippedFlags() []int {
+	ret := make([]int, 0)
+	for i := range fc.flags {
+		if len(fc.collected[i]) == 0 && !fc.skipped(i) {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+// lets the user select one of the flags without input and asks for it
+func (s *Session) addSkippedFlag(fc *flagCollector) error {
+	options := make([]string, 0)
+	indexes := make(map[string]int)
+	for _, i := range fc.skippedFlags() {
+		f := fc.flags[i].flag
+		label := fmt.Sprintf("--%s (%s)", f.Name, f.Usage)
+		options = append(options, label)
+		indexes[label] = i
+	}
+	selected, err := s.selectFn()("Select the flag to add: ", options)
+	if err != nil {
+		return nil
+	}
+	if i, ok := indexes[selected]; ok {
+		if err := fc.ask(i, 1, 1); err != nil && !errors.Is(err, ErrBack) {
+			return err
+		}
+	}
+	return nil
+}
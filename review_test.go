@@ -0,0 +1,178 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+func newReviewTestRoot() *cobra.Command {
+	root := &cobra.Command{Use: "main"}
+	cmd := &cobra.Command{Use: "cmd [item]", Args: cobra.ExactArgs(1), Run: func(cmd *cobra.Command, args []string) {}}
+	cmd.Flags().Int("count", 0, "the count")
+	cmd.Flags().String("env", "", "the env")
+	cmd.Flags().String("name", "", "the name")
+	cmd.MarkFlagRequired("env")
+	root.AddCommand(cmd)
+	return root
+}
+
+// returns a selector that answers with the given selections in order and
+// records the offered options of the review screen
+func scriptedSelector(selections []string, reviewOptions *[][]string) ic0bra.SelectFunc {
+	return func(promptString string, options []string) (string, error) {
+		if promptString == ic0bra.SELECT_SUB_CMD_PROMPT {
+			return "cmd", nil
+		}
+		if promptString == ic0bra.REVIEW_PROMPT {
+			*reviewOptions = append(*reviewOptions, options)
+		}
+		ret := selections[0]
+		selections = selections[1:]
+		return ret, nil
+	}
+}
+
+func TestReview_EditClearAdd(t *testing.T) {
+	root := newReviewTestRoot()
+	inputs := []string{
+		"",     // start the flag collection
+		"",     // count
+		"prod", // env
+		"n1",   // name
+		"a1",   // item
+		"n2",   // edit name
+		"test", // add env
+		"a2",   // edit item
+	}
+	selections := []string{
+		"--name: n1", ic0bra.REVIEW_EDIT,
+		"--env: prod", ic0bra.REVIEW_CLEAR,
+		ic0bra.REVIEW_CONFIRM, // env is missing
+		ic0bra.REVIEW_ADD_FLAG, "--env (the env)",
+		"[arg 1] <item>: a1", ic0bra.REVIEW_EDIT,
+		ic0bra.REVIEW_CONFIRM,
+	}
+	reviewOptions := make([][]string, 0)
+	var out bytes.Buffer
+	s := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader(strings.Join(inputs, "\n")+"\n")),
+		ic0bra.WithOutput(&out),
+		ic0bra.WithSelector(scriptedSelector(selections, &reviewOptions)),
+		ic0bra.WithReview(),
+	)
+	res, err := s.Run(root)
+	require.NoError(t, err)
+	assert.Equal(t, "main cmd --env test --name n2 a2", res.CommandLine)
+	assert.Equal(t, []string{"a2"}, res.Args)
+	assert.Contains(t, out.String(), "Input is incomplete: flag --env is required")
+
+	require.Len(t, reviewOptions, 6)
+	assert.Equal(t, []string{
+		ic0bra.REVIEW_CONFIRM,
+		"--env: prod",
+		"--name: n1",
+		"[arg 1] <item>: a1",
+		ic0bra.REVIEW_ADD_FLAG,
+		ic0bra.REVIEW_CANCEL,
+	}, reviewOptions[0])
+	assert.Contains(t, reviewOptions[2], "--env: (required, not set)")
+}
+
+func TestReview_Cancel(t *testing.T) {
+	root := newReviewTestRoot()
+	reviewOptions := make([][]string, 0)
+	s := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\n\nprod\n\na1\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(scriptedSelector([]string{ic0bra.REVIEW_CANCEL}, &reviewOptions)),
+		ic0bra.WithReview(),
+	)
+	res, err := s.Run(root)
+	assert.ErrorIs(t, err, ic0bra.ErrCancelled)
+	assert.True(t, res.Cancelled)
+	assert.Len(t, reviewOptions, 1)
+}
+
+func TestReview_FlagGroups(t *testing.T) {
+	newRoot := func(mark func(cmd *cobra.Command)) *cobra.Command {
+		root := &cobra.Command{Use: "main"}
+		cmd := &cobra.Command{Use: "cmd", Run: func(cmd *cobra.Command, args []string) {}}
+		cmd.Flags().String("a", "", "the a")
+		cmd.Flags().String("b", "", "the b")
+		cmd.Flags().String("name", "", "the name")
+		mark(cmd)
+		root.AddCommand(cmd)
+		return root
+	}
+
+	// the other flag of a mutually exclusive group can't be added
+	root := newRoot(func(cmd *cobra.Command) { cmd.MarkFlagsMutuallyExclusive("a", "b") })
+	addable := make([]string, 0)
+	selections := []string{"--a", ic0bra.REVIEW_ADD_FLAG, "--name (the name)", ic0bra.REVIEW_CONFIRM}
+	reviewOptions := make([][]string, 0)
+	selector := scriptedSelector(selections, &reviewOptions)
+	var out bytes.Buffer
+	res, err := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\nx\n\nn1\n")),
+		ic0bra.WithOutput(&out),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			if promptString == "Select the flag to add: " {
+				addable = options
+			}
+			return selector(promptString, options)
+		}),
+		ic0bra.WithReview(),
+	).Run(root)
+	require.NoError(t, err)
+	assert.Equal(t, "main cmd --a x --name n1", res.CommandLine)
+	assert.Equal(t, []string{"--name (the name)"}, addable)
+
+	// a one-required group has to be satisfied before the confirmation
+	root = newRoot(func(cmd *cobra.Command) { cmd.MarkFlagsOneRequired("a", "b") })
+	selections = []string{
+		"--a: x", ic0bra.REVIEW_CLEAR,
+		ic0bra.REVIEW_CONFIRM, // a or b is missing
+		ic0bra.REVIEW_ADD_FLAG, "--b (the b)",
+		ic0bra.REVIEW_CONFIRM,
+	}
+	out.Reset()
+	res, err = ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\nx\n\n\ny\n")),
+		ic0bra.WithOutput(&out),
+		ic0bra.WithSelector(scriptedSelector(selections, &reviewOptions)),
+		ic0bra.WithReview(),
+	).Run(root)
+	require.NoError(t, err)
+	assert.Equal(t, "main cmd --b y", res.CommandLine)
+	assert.Contains(t, out.String(), "Input is incomplete: one of the flags --a, --b is required")
+}
+
+func TestReview_FlagsUntouchedUntilConfirm(t *testing.T) {
+	root := newReviewTestRoot()
+	cmd, _, err := root.Find([]string{"cmd"})
+	require.NoError(t, err)
+	selections := []string{"--name: n1", ic0bra.REVIEW_EDIT, ic0bra.REVIEW_CONFIRM}
+	reviewOptions := make([][]string, 0)
+	selector := scriptedSelector(selections, &reviewOptions)
+	res, err := ic0bra.New(
+		// the edit of the name is left with '<'
+		ic0bra.WithInput(strings.NewReader("\n\nprod\nn1\na1\n<\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			assert.False(t, cmd.Flags().Lookup("name").Changed)
+			assert.Empty(t, cmd.Flags().Lookup("name").Value.String())
+			return selector(promptString, options)
+		}),
+		ic0bra.WithReview(),
+	).Run(root)
+	require.NoError(t, err)
+	assert.Equal(t, "main cmd --env prod --name n1 a1", res.CommandLine)
+	assert.Equal(t, "n1", cmd.Flags().Lookup("name").Value.String())
+}
@@ -0,0 +1,160 @@
+package ic0bra
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// entry in the sub command selection to call the current command itself,
+// offered if the command has sub commands but can run too
+const RUN_HERE = "▶ run %s here"
+
+// an entry of the sub command selection
+type cmdEntry struct {
+	label string
+	// nil for the entry to return to the parent command
+	cmd *cobra.Command
+	// true for the entry to call the current command
+	runHere bool
+}
+
+// annotation to exclude a command from the sub command selection
+const ANNOTATION_EXCLUDE = "ic0bra_exclude"
+
+// annotation to show a command at the top of the sub command selection, a
+// pinned command is shown even if it is hidden
+const ANNOTATION_PIN = "ic0bra_pin"
+
+// ExcludeCommand excludes the command from the sub command selection
+func ExcludeCommand(cmd *cobra.Command) {
+	setCommandAnnotation(cmd, ANNOTATION_EXCLUDE)
+}
+
+// PinCommand shows the command at the top of the sub command selection
+func PinCommand(cmd *cobra.Command) {
+	setCommandAnnotation(cmd, ANNOTATION_PIN)
+}
+
+func setCommandAnnotation(cmd *cobra.Command, annotation string) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[annotation] = "true"
+}
+
+func hasCommandAnnotation(cmd *cobra.Command, annotation string) bool {
+	_, ok := cmd.Annotations[annotation]
+	return ok
+}
+
+// produces the entries for the interactive selection of the sub commands of
+// the given command. Pinned commands come first, then the commands of the
+// cobra groups in the order of the groups, then the commands without group.
+func commandEntries(parent *cobra.Command) []cmdEntry {
+	pinned := make([]cmdEntry, 0)
+	grouped := make(map[string][]cmdEntry)
+	ungrouped := make([]cmdEntry, 0)
+	groupTitles := make(map[string]string)
+	for _, g := range parent.Groups() {
+		groupTitles[g.ID] = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(g.Title), ":"))
+	}
+	for _, cmd := range parent.Commands() {
+		if !isSelectable(cmd) {
+			continue
+		}
+		title, hasGroup := groupTitles[cmd.GroupID]
+		entry := cmdEntry{label: commandLabel(cmd, title), cmd: cmd}
+		switch {
+		case hasCommandAnnotation(cmd, ANNOTATION_PIN):
+			pinned = append(pinned, entry)
+		case hasGroup:
+			grouped[cmd.GroupID] = append(grouped[cmd.GroupID], entry)
+		default:
+			ungrouped = append(ungrouped, entry)
+		}
+	}
+	ret := pinned
+	for _, g := range parent.Groups() {
+		ret = append(ret, grouped[g.ID]...)
+	}
+	ret = append(ret, ungrouped...)
+	if len(ret) == 1 && ret[0].cmd.Name() == "help" {
+		// cobra adds the help command to a flat tool too, e.g. because of
+		// the installed interactive command, it alone needs no selection
+		return ret[:0]
+	}
+	return ret
+}
+
+// returns true if the command is offered in the sub command selection
+func isSelectable(cmd *cobra.Command) bool {
+	if isInternalCmd(cmd) || hasCommandAnnotation(cmd, ANNOTATION_EXCLUDE) {
+		return false
+	}
+	if hasCommandAnnotation(cmd, ANNOTATION_PIN) {
+		return true
+	}
+	return !cmd.Hidden && cmd.Name() != "completion"
+}
+
+// renders the group, the name, the aliases, the short description and the
+// deprecation of the command, so that the fuzzy search matches all of them
+func commandLabel(cmd *cobra.Command, group string) string {
+	label := cmd.Name()
+	if group != "" {
+		label = fmt.Sprintf("[%s] %s", group, label)
+	}
+	if len(cmd.Aliases) > 0 {
+		label += fmt.Sprintf(" (%s)", strings.Join(cmd.Aliases, ", "))
+	}
+	if cmd.Short != "" {
+		label += " — " + cmd.Short
+	}
+	if cmd.Deprecated != "" {
+		label += fmt.Sprintf(" ⚠️  deprecated: %s", cmd.Deprecated)
+	}
+	return label
+}
+
+func entryLabels(entries []cmdEntry) []string {
+	ret := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ret = append(ret, e.label)
+	}
+	return ret
+}
+
+// returns the entry for the selected label. Plain command names and
+// aliases are accepted too, e.g. from a custom selector.
+func findEntry(entries []cmdEntry, selected string) (cmdEntry, bool) {
+	for _, e := range entries {
+		if e.label == selected {
+			return e, true
+		}
+	}
+	for _, e := range entries {
+		if e.cmd != nil && !e.runHere && (e.cmd.Name() == selected || e.cmd.HasAlias(selected)) {
+			return e, true
+		}
+	}
+	return cmdEntry{}, false
+}
+
+// provides the description and the usage of the command of the entry
+func entryPreview(currentCmd *cobra.Command, entry cmdEntry) string {
+	if entry.cmd == nil {
+		return fmt.Sprintf("back to `%s`", currentCmd.Parent().CommandPath())
+	}
+	var sb strings.Builder
+	sb.WriteString(entry.cmd.CommandPath() + "\n\n")
+	if entry.cmd.Long != "" {
+		sb.WriteString(entry.cmd.Long + "\n\n")
+	} else if entry.cmd.Short != "" {
+		sb.WriteString(entry.cmd.Short + "\n\n")
+	}
+	// contains the aliases, examples, sub commands and flags
+	sb.WriteString(entry.cmd.UsageString())
+	return sb.String()
+}
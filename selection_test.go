@@ -0,0 +1,181 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+func newSelectionTestRoot() *cobra.Command {
+	root := &cobra.Command{Use: "main"}
+	deployCmd := &cobra.Command{
+		Use:     "deploy",
+		Aliases: []string{"d", "ship"},
+		Short:   "Deploy the app",
+		Long:    "Deploys the app to the given environment",
+		Example: "main deploy --env prod",
+		Run:     func(cmd *cobra.Command, args []string) {},
+	}
+	deployCmd.Flags().String("env", "", "the target environment")
+	root.AddCommand(deployCmd, &cobra.Command{Use: "status", Run: func(cmd *cobra.Command, args []string) {}})
+	return root
+}
+
+func TestSelection_LabelsAndPreview(t *testing.T) {
+	var options []string
+	var previews []string
+	s := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\n\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithExtendedSelector(func(promptString string, opts []string, cfg ic0bra.SelectConfig) (string, error) {
+			options = opts
+			for i := range opts {
+				previews = append(previews, cfg.Preview(i))
+			}
+			return opts[0], nil
+		}),
+	)
+	res, err := s.Run(newSelectionTestRoot())
+	require.NoError(t, err)
+	assert.Equal(t, "deploy", res.Command.Name())
+	assert.Equal(t, []string{"deploy (d, ship) — Deploy the app", "status"}, options)
+	require.Len(t, previews, 2)
+	assert.Contains(t, previews[0], "Deploys the app to the given environment")
+	assert.Contains(t, previews[0], "main deploy --env prod")
+	assert.Contains(t, previews[0], "d, ship")
+	assert.Contains(t, previews[0], "the target environment")
+}
+
+func TestSelection_Alias(t *testing.T) {
+	res, err := ic0bra.RunInteractive(newSelectionTestRoot(),
+		ic0bra.WithInput(strings.NewReader("\n\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "ship", nil
+		}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "deploy", res.Command.Name())
+}
+
+func TestSelection_GroupsHiddenDeprecated(t *testing.T) {
+	run := func(cmd *cobra.Command, args []string) {}
+	root := &cobra.Command{Use: "main"}
+	root.AddGroup(&cobra.Group{ID: "mgmt", Title: "Management Commands:"}, &cobra.Group{ID: "info", Title: "Info Commands:"})
+	secretCmd := &cobra.Command{Use: "secret", Hidden: true, Run: run}
+	debugCmd := &cobra.Command{Use: "debug", Hidden: true, Run: run}
+	internalCmd := &cobra.Command{Use: "internal", Run: run}
+	upCmd := &cobra.Command{Use: "up", Run: run}
+	ic0bra.PinCommand(debugCmd)
+	ic0bra.PinCommand(upCmd)
+	ic0bra.ExcludeCommand(internalCmd)
+	root.AddCommand(
+		&cobra.Command{Use: "version", GroupID: "info", Run: run},
+		&cobra.Command{Use: "create", GroupID: "mgmt", Run: run},
+		&cobra.Command{Use: "delete", GroupID: "mgmt", Run: run},
+		&cobra.Command{Use: "old", Deprecated: "use create instead", Run: run},
+		&cobra.Command{Use: "other", Run: run},
+		secretCmd, debugCmd, internalCmd, upCmd,
+	)
+	var options []string
+	res, err := ic0bra.RunInteractive(root,
+		ic0bra.WithInput(strings.NewReader("\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, opts []string) (string, error) {
+			options = opts
+			return "[Management Commands] delete", nil
+		}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "delete", res.Command.Name())
+	assert.Equal(t, []string{
+		"debug",
+		"up",
+		"[Management Commands] create",
+		"[Management Commands] delete",
+		"[Info Commands] version",
+		"old ⚠️  deprecated: use create instead",
+		"other",
+	}, options)
+}
+
+func TestSelection_RunHere(t *testing.T) {
+	root := &cobra.Command{Use: "main"}
+	statusCmd := &cobra.Command{Use: "status", Run: func(cmd *cobra.Command, args []string) {}}
+	statusCmd.Flags().Bool("all", false, "show all")
+	statusCmd.AddCommand(&cobra.Command{Use: "details", Run: func(cmd *cobra.Command, args []string) {}})
+	root.AddCommand(statusCmd)
+	offered := make([][]string, 0)
+	selections := []string{"status", fmt.Sprintf(ic0bra.RUN_HERE, "status")}
+	res, err := ic0bra.RunInteractive(root,
+		ic0bra.WithInput(strings.NewReader("\ntrue\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			offered = append(offered, options)
+			ret := selections[0]
+			selections = selections[1:]
+			return ret, nil
+		}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, statusCmd, res.Command)
+	assert.Equal(t, "main status --all", res.CommandLine)
+	// the root can't run
+	assert.Equal(t, []string{"status"}, offered[0])
+	assert.Equal(t, []string{ic0bra.PARENT_CMD, "▶ run status here", "details"}, offered[1])
+}
+
+func TestSelection_RunHereNotForStartCommand(t *testing.T) {
+	executed := 0
+	root := &cobra.Command{Use: "main"}
+	root.Run = func(cmd *cobra.Command, args []string) {
+		executed++
+		require.Less(t, executed, 2, "the wizard was started again")
+		require.NoError(t, ic0bra.ExecuteInteractive(cmd,
+			ic0bra.WithInput(strings.NewReader("\n")),
+			ic0bra.WithOutput(&bytes.Buffer{}),
+			ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+				assert.NotContains(t, options, fmt.Sprintf(ic0bra.RUN_HERE, "main"))
+				return "status", nil
+			}),
+		))
+	}
+	statusCmd := &cobra.Command{Use: "status", Run: func(cmd *cobra.Command, args []string) {}}
+	root.AddCommand(statusCmd)
+	root.SetArgs([]string{})
+	require.NoError(t, root.Execute())
+	assert.Equal(t, 1, executed)
+}
+
+func TestSelection_SelectorCancels(t *testing.T) {
+	errSelector := errors.New("no terminal")
+	tests := []struct {
+		name      string
+		err       error
+		cancelled bool
+	}{
+		{name: "cancel", err: ic0bra.ErrCancelled, cancelled: true},
+		{name: "error", err: errSelector},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := ic0bra.RunInteractive(newSelectionTestRoot(),
+				ic0bra.WithInput(strings.NewReader("")),
+				ic0bra.WithOutput(&bytes.Buffer{}),
+				ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+					return "", test.err
+				}),
+			)
+			assert.ErrorIs(t, err, test.err)
+			assert.Equal(t, test.cancelled, res != nil && res.Cancelled)
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package ic0bra
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/ktr0731/go-fuzzyfinder"
+	"golang.org/x/term"
+)
+
+// SelectFunc presents the options to the user and returns the selected one.
+// To abort the selection it returns ErrCancelled or fuzzyfinder.ErrAbort,
+// depending on the prompt the wizard then steps back or is canceled. The
+// same applies to ExtendedSelectFunc and MultiSelectFunc.
+type SelectFunc func(promptString string, options []string) (string, error)
+
+// SelectConfig holds the optional settings of a selection
+type SelectConfig struct {
+	// provides the preview text for the option with the given index
+	Preview func(i int) string
+	// initial input of the search
+	Query string
+}
+
+// ExtendedSelectFunc presents the options to the user, taking the optional
+// settings into account, and returns the selected one
+type ExtendedSelectFunc func(promptString string, options []string, cfg SelectConfig) (string, error)
+
+// MultiSelectFunc presents the options to the user and returns the selected ones
+type MultiSelectFunc func(promptString string, options []string, cfg SelectConfig) ([]string, error)
+
+// ConfirmFunc is called with the resulting program call before it is
+// returned to the caller. If it returns false the run is canceled.
+type ConfirmFunc func(commandLine string) bool
+
+// Theme defines the colors used for the different kinds of output
+type Theme struct {
+	// used for hints and explanations
+	Info *color.Color
+	// used to print the resulting program call
+	CommandLine *color.Color
+	// used for warnings, e.g. invalid input
+	Warning *color.Color
+}
+
+// DefaultTheme returns the colors used when no theme is configured
+func DefaultTheme() Theme {
+	return Theme{
+		Info:        color.New(color.FgHiBlue),
+		CommandLine: color.New(color.FgYellow),
+		Warning:     color.New(color.FgRed),
+	}
+}
+
+// Session holds the configuration of an interactive run. Sessions don't
+// share any state, so multiple sessions can be used in parallel.
+type Session struct {
+	in            io.Reader
+	out           io.Writer
+	selector      SelectFunc
+	extSelector   ExtendedSelectFunc
+	multiSelector MultiSelectFunc
+	histProvider  HistoryProvider
+	confirm       ConfirmFunc
+	theme         Theme
+	review        bool
+	pickFlags     bool
+	// see WithHiddenFlags, WithDeprecatedFlags and WithShorthands
+	hiddenFlags     bool
+	deprecatedFlags bool
+	shorthands      bool
+	// the prompters of the session by pflag type
+	prompters map[string]FlagPrompter
+}
+
+// Option configures a Session
+type Option func(*Session)
+
+// New creates a session for interactive runs. Without options the session
+// reads from stdin, writes to stdout and uses a fuzzy finder for selections.
+func New(opts ...Option) *Session {
+	s := &Session{
+		out:   os.Stdout,
+		theme: DefaultTheme(),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	if fp, ok := s.histProvider.(*FileHistoryProvider); ok && s.selector != nil && fp.selector == nil {
+		// the history selection should use the configured selector too
+		withSelector := *fp
+		withSelector.selector = s.selector
+		s.histProvider = &withSelector
+	}
+	return s
+}
+
+// WithInput sets the stream the user input is read from
+func WithInput(r io.Reader) Option {
+	return func(s *Session) {
+		s.in = r
+	}
+}
+
+// WithOutput sets the stream prompts and messages are written to
+func WithOutput(w io.Writer) Option {
+	return func(s *Session) {
+		s.out = w
+	}
+}
+
+// WithSelector replaces the fuzzy finder used to select sub commands and
+// history entries
+func WithSelector(f SelectFunc) Option {
+	return func(s *Session) {
+		s.selector = f
+	}
+}
+
+// WithExtendedSelector replaces the fuzzy finder used to select sub commands
+// with a function that gets the optional settings of the selection, e.g. the
+// initial query. It takes precedence over WithSelector.
+func WithExtendedSelector(f ExtendedSelectFunc) Option {
+	return func(s *Session) {
+		s.extSelector = f
+	}
+}
+
+// WithMultiSelector replaces the fuzzy finder used to select multiple
+// entries, e.g. the optional flags in the flag picker
+func WithMultiSelector(f MultiSelectFunc) Option {
+	return func(s *Session) {
+		s.multiSelector = f
+	}
+}
+
+// WithHistoryProvider enables proposals from a history for flag input
+func WithHistoryProvider(p HistoryProvider) Option {
+	return func(s *Session) {
+		s.histProvider = p
+	}
+}
+
+// WithConfirm replaces the final yes/no question. Without this option the
+// session asks on its input stream whether the program should continue. It
+// isn't used if the review screen is enabled.
+func WithConfirm(f ConfirmFunc) Option {
+	return func(s *Session) {
+		s.confirm = f
+	}
+}
+
+// WithoutConfirm skips the final yes/no question
+func WithoutConfirm() Option {
+	return WithConfirm(func(string) bool { return true })
+}
+
+// WithTheme sets the colors used for the output
+func WithTheme(t Theme) Option {
+	return func(s *Session) {
+		s.theme = t
+	}
+}
+
+// reports whether stdin is a terminal - separated for better testability
+var stdinIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// returns true if the user input can be read, either from the configured
+// input or from stdin attached to a terminal
+func (s *Session) interactiveInput() bool {
+	return s.in != nil || stdinIsTerminal()
+}
+
+// returns a reader for the user input of one run
+func (s *Session) newReader() *bufio.Reader {
+	if s.in != nil {
+		return bufio.NewReader(s.in)
+	}
+	return readerFactory()
+}
+
+func (s *Session) selectFn() SelectFunc {
+	if s.selector != nil {
+		return s.selector
+	}
+	return selectionFactory
+}
+
+func (s *Session) extendedSelectFn() ExtendedSelectFunc {
+	if s.extSelector != nil {
+		return s.extSelector
+	}
+	if s.selector != nil {
+		return func(promptString string, options []string, cfg SelectConfig) (string, error) {
+			return s.selector(promptString, options)
+		}
+	}
+	return extendedSelectionFactory
+}
+
+func (s *Session) multiSelectFn() MultiSelectFunc {
+	if s.multiSelector != nil {
+		return s.multiSelector
+	}
+	return multiSelectionFactory
+}
+
+// returns true if the user aborted a selection
+func aborted(err error) bool {
+	return errors.Is(err, ErrCancelled) || errors.Is(err, fuzzyfinder.ErrAbort)
+}
@@ -0,0 +1,127 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+type sessionTestCmds struct {
+	root  *cobra.Command
+	one   *cobra.Command
+	two   *cobra.Command
+	name  string
+	count int
+}
+
+func newSessionTestCmds() *sessionTestCmds {
+	ret := &sessionTestCmds{}
+	ret.root = &cobra.Command{Use: "main"}
+	ret.one = &cobra.Command{Use: "one", Run: func(cmd *cobra.Command, args []string) {}}
+	ret.two = &cobra.Command{Use: "two", Run: func(cmd *cobra.Command, args []string) {}}
+	ret.two.Flags().StringVar(&ret.name, "name", "", "a string flag")
+	ret.two.Flags().IntVar(&ret.count, "count", 0, "an int flag")
+	ret.root.AddCommand(ret.one, ret.two)
+	return ret
+}
+
+func TestSession_ParallelRuns(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expectedName  string
+		expectedCount int
+	}{
+		{name: "first", input: "\n3\nfirst\n\n", expectedName: "first", expectedCount: 3},
+		{name: "second", input: "\n7\nsecond\n\n", expectedName: "second", expectedCount: 7},
+		{name: "third", input: "\n\nthird\n\n", expectedName: "third", expectedCount: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			cmds := newSessionTestCmds()
+			var out bytes.Buffer
+			s := ic0bra.New(
+				ic0bra.WithInput(strings.NewReader(test.input)),
+				ic0bra.WithOutput(&out),
+				ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+					return "two", nil
+				}),
+			)
+			res, err := s.Run(cmds.root)
+			require.NoError(t, err)
+			require.Equal(t, cmds.two, res.Command)
+			assert.Equal(t, test.expectedName, cmds.name)
+			assert.Equal(t, test.expectedCount, cmds.count)
+			assert.Contains(t, out.String(), "resulting program call")
+		})
+	}
+}
+
+func TestSession_Confirm(t *testing.T) {
+	cmds := newSessionTestCmds()
+	var confirmedLine string
+	s := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\n\nabc\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "two", nil
+		}),
+		ic0bra.WithConfirm(func(commandLine string) bool {
+			confirmedLine = commandLine
+			return false
+		}),
+	)
+	res, err := s.Run(cmds.root)
+	assert.ErrorIs(t, err, ic0bra.ErrCancelled)
+	assert.True(t, res.Cancelled)
+	assert.Equal(t, "main two --name abc", confirmedLine)
+}
+
+func TestSession_Result(t *testing.T) {
+	cmds := newSessionTestCmds()
+	s := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\n5\nsome name\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "two", nil
+		}),
+	)
+	res, err := s.Run(cmds.root)
+	require.NoError(t, err)
+	assert.False(t, res.Cancelled)
+	assert.Equal(t, cmds.two, res.Command)
+	assert.Equal(t, []string{"main", "two"}, res.CommandPath)
+	assert.Equal(t, "main two --count 5 --name some\\ name", res.CommandLine)
+	assert.Equal(t, map[string]ic0bra.FlagValue{
+		"count": {Type: "int", Values: []string{"5"}},
+		"name":  {Type: "string", Values: []string{"some name"}},
+	}, res.Flags)
+}
+
+func TestSession_Cancel(t *testing.T) {
+	cmds := newSessionTestCmds()
+	s := ic0bra.New(
+		ic0bra.WithInput(strings.NewReader("\n\n\nno\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			return "two", nil
+		}),
+	)
+	res, err := s.Run(cmds.root)
+	assert.ErrorIs(t, err, ic0bra.ErrCancelled)
+	require.NotNil(t, res)
+	assert.True(t, res.Cancelled)
+	assert.Equal(t, "main two", res.CommandLine)
+}
+
+func TestSession_NoSubCommands(t *testing.T) {
+	_, err := ic0bra.New(ic0bra.WithOutput(&bytes.Buffer{})).Run(&cobra.Command{Use: "flat"})
+	assert.ErrorIs(t, err, ic0bra.ErrNoSubCommands)
+}
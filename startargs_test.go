@@ -0,0 +1,88 @@
+package ic0bra_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/okieoth/ic0bra"
+	"github.com/spf13/cobra"
+)
+
+// builds `main cluster node list|add` with a persistent --region flag on
+// `cluster` and a --name flag on `add`
+func newStartArgsTestRoot() *cobra.Command {
+	root := &cobra.Command{Use: "main"}
+	clusterCmd := &cobra.Command{Use: "cluster"}
+	clusterCmd.PersistentFlags().String("region", "", "the region")
+	nodeCmd := &cobra.Command{Use: "node"}
+	addCmd := &cobra.Command{Use: "add", Run: func(cmd *cobra.Command, args []string) {}}
+	addCmd.Flags().String("name", "", "the node name")
+	nodeCmd.AddCommand(&cobra.Command{Use: "list", Run: func(cmd *cobra.Command, args []string) {}}, addCmd)
+	clusterCmd.AddCommand(nodeCmd)
+	root.AddCommand(clusterCmd)
+	return root
+}
+
+func TestRunArgs_PartialPath(t *testing.T) {
+	root := newStartArgsTestRoot()
+	var offered []string
+	var out bytes.Buffer
+	res, err := ic0bra.RunInteractiveArgs(root, []string{"cluster", "node", "--region", "eu"},
+		// only the name is asked for
+		ic0bra.WithInput(strings.NewReader("\nn1\n\n")),
+		ic0bra.WithOutput(&out),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			offered = options
+			return "add", nil
+		}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"add", "list"}, offered)
+	assert.Equal(t, "main cluster node add --region eu --name n1", res.CommandLine)
+	assert.Equal(t, []string{"eu"}, res.Flags["region"].Values)
+	assert.NotContains(t, out.String(), "--region :")
+	region, _ := res.Command.InheritedFlags().GetString("region")
+	assert.Equal(t, "eu", region)
+}
+
+func TestRunArgs_Leaf(t *testing.T) {
+	root := &cobra.Command{Use: "main"}
+	deployCmd := &cobra.Command{Use: "deploy [target]", Args: cobra.ExactArgs(1), Run: func(cmd *cobra.Command, args []string) {}}
+	deployCmd.Flags().String("env", "", "the env")
+	root.AddCommand(deployCmd)
+	res, err := ic0bra.RunInteractiveArgs(root, []string{"deploy", "web", "--env", "prod"},
+		ic0bra.WithInput(strings.NewReader("\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "main deploy --env prod web", res.CommandLine)
+	assert.Equal(t, []string{"web"}, res.Args)
+}
+
+func TestInstall_PartialPath(t *testing.T) {
+	root := newStartArgsTestRoot()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	var offered []string
+	require.NoError(t, ic0bra.Install(root, ic0bra.WithSessionOptions(
+		ic0bra.WithInput(strings.NewReader("\nn2\n\n")),
+		ic0bra.WithOutput(&bytes.Buffer{}),
+		ic0bra.WithSelector(func(promptString string, options []string) (string, error) {
+			offered = options
+			return "add", nil
+		}),
+	)))
+	root.SetArgs([]string{"cluster", "node", "-i", "--region", "eu"})
+	require.NoError(t, root.Execute())
+	assert.Equal(t, []string{"add", "list"}, offered)
+	addCmd, _, err := root.Find([]string{"cluster", "node", "add"})
+	require.NoError(t, err)
+	name, _ := addCmd.Flags().GetString("name")
+	region, _ := addCmd.Flags().GetString("region")
+	assert.Equal(t, "n2", name)
+	assert.Equal(t, "eu", region)
+}